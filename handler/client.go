@@ -0,0 +1,311 @@
+package handler
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxErrorBytes caps the size of the error bodies read by DecodeResponse.
+const maxErrorBytes = 1 << 20
+
+var ErrCall = errors.New("call error")
+
+// ClientError is returned by Call when the server answers with a status other than 2xx.
+// Body holds the decoded ErrResponse, or the equivalent members of problem details.
+// Handlers returning it are served with 500, unless they forward it (see Forward).
+type ClientError struct {
+	Status int
+	Header http.Header
+	Body   ErrResponse
+}
+
+func (e *ClientError) Error() string {
+	return cmp.Or(e.Body.Error, http.StatusText(e.Status))
+}
+
+// Forward returns an error that is served with the same status and message as the upstream response.
+func (e *ClientError) Forward() *StatusError {
+	return NewStatusError(e.Status, e)
+}
+
+// Unwrap returns the field errors reported by the server, if any.
+func (e *ClientError) Unwrap() error {
+	if len(e.Body.Fields) == 0 {
+		return nil
+	}
+
+	return e.Body.Fields
+}
+
+// Call sends a request to an endpoint served by a handler of this package, and decodes its response.
+// The request is built by NewRequest, and the response decoded by DecodeResponse. A nil client means http.DefaultClient.
+// Headers common to all requests (eg. Authorization) can be set by the client's transport.
+func Call[Args any, In any, Out any](ctx context.Context, client *http.Client, method, pattern string, args Args, in In) (Out, error) { //nolint:ireturn
+	var out Out
+
+	req, err := NewRequest(ctx, method, pattern, args, in)
+	if err != nil {
+		return out, err
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return out, errors.Join(ErrCall, err)
+	}
+
+	defer res.Body.Close()
+
+	return DecodeResponse[Out](res)
+}
+
+// NewRequest returns a request for an endpoint served by a handler of this package.
+// Pattern is the URL of the endpoint, along with the wildcards of its route (eg. "https://shop.example/orders/{pk}").
+// Args are encoded via their `in` struct tags, as InputFromRequest reads them: into the wildcards of the pattern,
+// the query string, headers and cookies. Nil pointers, empty slices and the zero values of the fields other than wildcards
+// are left out, whereas the values of pointers are always sent. In is sent as JSON, unless it is struct{}.
+func NewRequest[Args any, In any](ctx context.Context, method, pattern string, args Args, in In) (*http.Request, error) {
+	var (
+		body    io.Reader
+		cookies []*http.Cookie
+		header  = http.Header{}
+		path    = map[string][]string{}
+		query   = url.Values{}
+	)
+
+	for _, param := range inParams(reflect.TypeFor[Args]()) {
+		values, err := argValues(reflect.ValueOf(args).FieldByIndex(param.field.Index), param.source != sourcePath)
+		if err != nil {
+			return nil, errors.Join(ErrCall, errors.New("cannot encode "+param.name+": "+err.Error())) //nolint:err113
+		}
+
+		if !param.explode && len(values) > 0 {
+			values = []string{strings.Join(values, ",")}
+		}
+
+		for _, v := range values {
+			switch param.source {
+			case sourcePath:
+				path[param.name] = append(path[param.name], v)
+			case sourceHeader:
+				header.Add(param.name, v)
+			case sourceCookie:
+				cookies = append(cookies, &http.Cookie{Name: param.name, Value: v}) //nolint:exhaustruct
+			case sourceQuery:
+				query.Add(param.name, v)
+			}
+		}
+	}
+
+	target, err := expandPattern(pattern, path)
+	if err != nil {
+		return nil, errors.Join(ErrCall, err)
+	}
+
+	if len(query) > 0 {
+		q := target.Query()
+
+		for k, v := range query {
+			q[k] = append(q[k], v...)
+		}
+
+		target.RawQuery = q.Encode()
+	}
+
+	if reflect.TypeFor[In]() != reflect.TypeFor[struct{}]() {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return nil, errors.Join(ErrCall, err)
+		}
+
+		body = bytes.NewReader(b)
+		header.Set("Content-Type", MediaTypeJSON)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), body)
+	if err != nil {
+		return nil, errors.Join(ErrCall, err)
+	}
+
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+
+	req.Header.Set("Accept", MediaTypeJSON)
+
+	return req, nil
+}
+
+// DecodeResponse decodes the JSON body of a response served by a handler of this package.
+// Responses without a body, and any response when Out is struct{}, leave Out to its zero value.
+// Statuses other than 2xx are returned as a *ClientError.
+func DecodeResponse[Out any](res *http.Response) (Out, error) { //nolint:ireturn
+	var out Out
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return out, newClientError(res)
+	}
+
+	if reflect.TypeFor[Out]() == reflect.TypeFor[struct{}]() || !bodyAllowed(res.StatusCode) {
+		return out, nil
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&out); err != nil && !errors.Is(err, io.EOF) {
+		return out, errors.Join(ErrCall, err)
+	}
+
+	return out, nil
+}
+
+// newClientError reads the error served in the response, either as an ErrResponse or as problem details.
+// Bodies that cannot be decoded are ignored, the error then carries the status text.
+func newClientError(res *http.Response) *ClientError {
+	var body struct {
+		Error         string      `json:"error"`
+		Fields        FieldErrors `json:"fields"`
+		CorrelationID string      `json:"correlationId"`
+		Title         string      `json:"title"`
+		Detail        string      `json:"detail"`
+		Errors        FieldErrors `json:"errors"`
+	}
+
+	_ = json.NewDecoder(io.LimitReader(res.Body, maxErrorBytes)).Decode(&body)
+
+	if len(body.Fields) == 0 {
+		body.Fields = body.Errors
+	}
+
+	return &ClientError{
+		Status: res.StatusCode,
+		Header: res.Header,
+		Body: ErrResponse{
+			Error:         cmp.Or(body.Error, body.Detail, body.Title),
+			Fields:        body.Fields,
+			CorrelationID: body.CorrelationID,
+		},
+	}
+}
+
+// expandPattern replaces the wildcards of the pattern with the escaped path values, and parses the resulting URL.
+// The values of wildcards matching the remainder of the path (eg. {path...}) keep their slashes.
+func expandPattern(pattern string, values map[string][]string) (*url.URL, error) {
+	var missing []string
+
+	expanded := wildcard.ReplaceAllStringFunc(strings.ReplaceAll(pattern, "{$}", ""), func(match string) string {
+		groups := wildcard.FindStringSubmatch(match)
+
+		if len(values[groups[1]]) == 0 {
+			missing = append(missing, groups[1])
+
+			return match
+		}
+
+		if groups[2] == "" {
+			return url.PathEscape(values[groups[1]][0])
+		}
+
+		segments := strings.Split(values[groups[1]][0], "/")
+		for i, s := range segments {
+			segments[i] = url.PathEscape(s)
+		}
+
+		return strings.Join(segments, "/")
+	})
+
+	if len(missing) > 0 {
+		return nil, errors.New("no value for the wildcards " + strings.Join(missing, ", ") + " of " + pattern) //nolint:err113
+	}
+
+	return url.Parse(expanded) //nolint:wrapcheck
+}
+
+// argValues returns the values of a field, formatted the way InputFromRequest parses them.
+// Nil pointers and empty slices are left out, as well as zero values when omitZero is set, unless they are pointed to.
+func argValues(v reflect.Value, omitZero bool) ([]string, error) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+
+		v, omitZero = v.Elem(), false
+	}
+
+	if !isSlice(v.Type()) {
+		if omitZero && v.IsZero() {
+			return nil, nil
+		}
+
+		s, err := formatArg(v)
+		if err != nil {
+			return nil, err
+		}
+
+		return []string{s}, nil
+	}
+
+	values := make([]string, 0, v.Len())
+
+	for i := range v.Len() {
+		elem := v.Index(i)
+		if elem.Kind() == reflect.Ptr {
+			if elem.IsNil() {
+				continue
+			}
+
+			elem = elem.Elem()
+		}
+
+		s, err := formatArg(elem)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, s)
+	}
+
+	return values, nil
+}
+
+// formatArg formats a single value.
+func formatArg(v reflect.Value) (string, error) {
+	switch v.Kind() { //nolint:exhaustive
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	case reflect.Struct:
+		switch v.Type() {
+		case reflect.TypeFor[time.Time]():
+			return v.Interface().(time.Time).Format(time.RFC3339Nano), nil //nolint:forcetypeassert
+		case reflect.TypeFor[url.URL]():
+			u := v.Interface().(url.URL) //nolint:forcetypeassert
+
+			return u.String(), nil
+		}
+	}
+
+	return "", errors.New("unsupported type " + v.Type().String()) //nolint:err113
+}
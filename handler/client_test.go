@@ -0,0 +1,185 @@
+package handler_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/luca-arch/go-goodies/handler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type CallArgs struct {
+	PK      int        `in:"pk,path"`
+	Path    string     `in:"path,path"`
+	Tags    []string   `in:"tag"`
+	IDs     []int64    `in:"ids,explode=false"`
+	From    *time.Time `in:"from"`
+	Limit   *int       `in:"limit"`
+	Deleted *bool      `in:"deleted"`
+	Tenant  string     `in:"X-Tenant-Id,header"`
+	Session string     `in:"session,cookie"`
+}
+
+type Receipt struct {
+	Args  CallArgs `json:"args"`
+	Order Order    `json:"order"`
+}
+
+func TestCall(t *testing.T) {
+	t.Parallel()
+
+	router := handler.NewRouter(nil)
+
+	router.MustHandle("POST /orders/{pk}/files/{path...}", handler.New(func(_ context.Context, r *handler.Request[CallArgs, Order]) (*handler.Response[Receipt], error) {
+		return handler.Created("/orders/1", Receipt{Args: r.Args, Order: r.In}), nil
+	}))
+
+	router.MustHandle("DELETE /orders/{pk}", handler.New(func(_ context.Context, _ *handler.Request[UpdateArgs, struct{}]) (*handler.Response[struct{}], error) {
+		return handler.NoContent(), nil
+	}))
+
+	router.MustHandle("GET /orders/{pk}", handler.WithArgsOutput(nil, func(_ context.Context, _ UpdateArgs) (Order, error) {
+		return Order{}, handler.NotFound(errors.New("no such order")) //nolint:exhaustruct
+	}, handler.ProblemDetails()))
+
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+
+	from := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	limit, deleted := 0, false
+	args := CallArgs{PK: 7, Path: "a b/c.txt", Tags: []string{"x", "y"}, IDs: []int64{1, 2}, From: &from, Limit: &limit, Deleted: &deleted, Tenant: "acme", Session: "s3cr3t"}
+	order := Order{Customer: "alice", Priority: nil, Items: []Item{{Name: "pen", Quantity: 2}}, Min: 0, Max: 1}
+
+	tests := map[string]struct {
+		call       func() (any, error)
+		want       any
+		wantStatus int
+		wantErr    string
+	}{
+		"args and body": {
+			call: func() (any, error) {
+				return handler.Call[CallArgs, Order, Receipt](context.Background(), srv.Client(), http.MethodPost, srv.URL+"/orders/{pk}/files/{path...}", args, order)
+			},
+			want:       Receipt{Args: args, Order: order},
+			wantStatus: 0,
+			wantErr:    "",
+		},
+		"no content": {
+			call: func() (any, error) {
+				return handler.Call[UpdateArgs, struct{}, struct{}](context.Background(), nil, http.MethodDelete, srv.URL+"/orders/{pk}", UpdateArgs{PK: 7}, struct{}{})
+			},
+			want:       struct{}{},
+			wantStatus: 0,
+			wantErr:    "",
+		},
+		"field errors": {
+			call: func() (any, error) {
+				return handler.Call[CallArgs, Order, Receipt](context.Background(), srv.Client(), http.MethodPost, srv.URL+"/orders/{pk}/files/{path...}", args, Order{}) //nolint:exhaustruct
+			},
+			want:       Receipt{}, //nolint:exhaustruct
+			wantStatus: http.StatusBadRequest,
+			wantErr:    "invalid input",
+		},
+		"problem details": {
+			call: func() (any, error) {
+				return handler.Call[UpdateArgs, struct{}, Order](context.Background(), srv.Client(), http.MethodGet, srv.URL+"/orders/{pk}", UpdateArgs{PK: 7}, struct{}{})
+			},
+			want:       Order{}, //nolint:exhaustruct
+			wantStatus: http.StatusNotFound,
+			wantErr:    "no such order",
+		},
+		"missing wildcard": {
+			call: func() (any, error) {
+				return handler.Call[UpdateArgs, struct{}, Order](context.Background(), srv.Client(), http.MethodGet, srv.URL+"/orders/{pk}/lines/{line}", UpdateArgs{PK: 0}, struct{}{})
+			},
+			want:       Order{}, //nolint:exhaustruct
+			wantStatus: 0,
+			wantErr:    "no value for the wildcards line",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			out, err := test.call()
+
+			assert.Equal(t, test.want, out)
+
+			if test.wantErr == "" {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.ErrorContains(t, err, test.wantErr)
+
+			if test.wantStatus == 0 {
+				assert.ErrorIs(t, err, handler.ErrCall)
+
+				return
+			}
+
+			var clientErr *handler.ClientError
+
+			require.ErrorAs(t, err, &clientErr)
+			assert.Equal(t, test.wantStatus, clientErr.Status)
+		})
+	}
+
+	t.Run("errors are forwarded explicitly", func(t *testing.T) {
+		t.Parallel()
+
+		for forward, want := range map[bool]int{true: http.StatusNotFound, false: http.StatusInternalServerError} {
+			h := handler.WithOutput(nil, func(ctx context.Context) (Order, error) {
+				order, err := handler.Call[UpdateArgs, struct{}, Order](ctx, srv.Client(), http.MethodGet, srv.URL+"/orders/{pk}", UpdateArgs{PK: 7}, struct{}{})
+
+				var clientErr *handler.ClientError
+				if errors.As(err, &clientErr) && forward {
+					return order, clientErr.Forward()
+				}
+
+				return order, err
+			})
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, want, w.Code)
+			assert.JSONEq(t, `{"error":"no such order"}`, w.Body.String())
+		}
+	})
+
+	t.Run("field errors are unwrapped", func(t *testing.T) {
+		t.Parallel()
+
+		var fieldErrs handler.FieldErrors
+
+		_, err := handler.Call[CallArgs, Order, Receipt](context.Background(), srv.Client(), http.MethodPost, srv.URL+"/orders/{pk}/files/{path...}", args, Order{}) //nolint:exhaustruct
+
+		require.ErrorAs(t, err, &fieldErrs)
+		assert.Equal(t, "customer", fieldErrs[0].Field)
+	})
+}
+
+func TestNewRequest(t *testing.T) {
+	t.Parallel()
+
+	from := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	limit, deleted := 0, false
+	args := CallArgs{PK: 0, Path: "a b/c.txt", Tags: []string{"x", "y"}, IDs: []int64{1, 2}, From: &from, Limit: &limit, Deleted: &deleted, Tenant: "acme", Session: ""}
+
+	r, err := handler.NewRequest(context.Background(), http.MethodGet, "https://shop.example/orders/{pk}/files/{path...}?v=1", args, struct{}{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "https://shop.example/orders/0/files/a%20b/c.txt?deleted=false&from=2024-05-01T12%3A30%3A00Z&ids=1%2C2&limit=0&tag=x&tag=y&v=1", r.URL.String())
+	assert.Equal(t, "acme", r.Header.Get("X-Tenant-Id"))
+	assert.Empty(t, r.Header.Get("Cookie"))
+	assert.Empty(t, r.Header.Get("Content-Type"))
+	assert.Nil(t, r.Body)
+}
@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"maps"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Media types of the request bodies decoded out of the box.
+const (
+	MediaTypeForm      = "application/x-www-form-urlencoded"
+	MediaTypeJSON      = "application/json"
+	MediaTypeMultipart = "multipart/form-data"
+	MediaTypeXML       = "application/xml"
+)
+
+// defaultMaxMemory is the size of the multipart bodies kept in memory, as in net/http.
+const defaultMaxMemory = 32 << 20
+
+var (
+	ErrTrailingData         = errors.New("unexpected data after the JSON body")
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+
+	// DefaultDecoders is the BodyDecoder of the handlers that don't use the Decoder option.
+	DefaultDecoders = NewDecoders() //nolint:gochecknoglobals
+)
+
+// BodyDecoder decodes the request's body into v.
+type BodyDecoder interface {
+	Decode(r *http.Request, v any) error
+}
+
+// DecoderFunc is an adapter to use ordinary functions as BodyDecoder.
+type DecoderFunc func(r *http.Request, v any) error
+
+// Decode calls f(r, v).
+func (f DecoderFunc) Decode(r *http.Request, v any) error {
+	return f(r, v)
+}
+
+// Decoders picks the BodyDecoder from the request's Content-Type. Requests with no Content-Type are decoded as JSON.
+type Decoders struct {
+	mu     sync.RWMutex
+	byType map[string]BodyDecoder
+}
+
+// NewDecoders returns a registry of the JSON, form, multipart and XML decoders.
+func NewDecoders() *Decoders {
+	d := &Decoders{
+		mu:     sync.RWMutex{},
+		byType: map[string]BodyDecoder{},
+	}
+
+	return d.
+		Register(MediaTypeForm, FormDecoder{}).
+		Register(MediaTypeJSON, JSONDecoder{}).
+		Register(MediaTypeMultipart, MultipartDecoder{MaxMemory: defaultMaxMemory}).
+		Register(MediaTypeXML, XMLDecoder{}).
+		Register("text/xml", XMLDecoder{})
+}
+
+// RegisterDecoder registers a BodyDecoder for the given media type on the DefaultDecoders.
+func RegisterDecoder(mediaType string, dec BodyDecoder) {
+	DefaultDecoders.Register(mediaType, dec)
+}
+
+// Register sets the BodyDecoder of the given media type, eg. "application/json".
+func (d *Decoders) Register(mediaType string, dec BodyDecoder) *Decoders {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.byType[mediaType] = dec
+
+	return d
+}
+
+// withJSON returns a copy of the registry that uses dec for JSON bodies, unless a custom decoder was registered for them.
+func (d *Decoders) withJSON(dec JSONDecoder) *Decoders {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if _, ok := d.byType[MediaTypeJSON].(JSONDecoder); !ok {
+		return d
+	}
+
+	clone := &Decoders{
+		mu:     sync.RWMutex{},
+		byType: maps.Clone(d.byType),
+	}
+
+	clone.byType[MediaTypeJSON] = dec
+
+	return clone
+}
+
+// Decode decodes the request's body with the decoder registered for its media type.
+// It returns ErrUnsupportedMediaType, served with 415, if there is none.
+func (d *Decoders) Decode(r *http.Request, v any) error {
+	mediaType := MediaTypeJSON
+
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		parsed, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			return NewStatusError(http.StatusUnsupportedMediaType, errors.Join(ErrUnsupportedMediaType, err))
+		}
+
+		mediaType = parsed
+	}
+
+	d.mu.RLock()
+	dec, ok := d.byType[mediaType]
+	d.mu.RUnlock()
+
+	if !ok {
+		return NewStatusError(http.StatusUnsupportedMediaType, errors.Join(ErrUnsupportedMediaType, errors.New(mediaType))) //nolint:err113
+	}
+
+	return dec.Decode(r, v) //nolint:wrapcheck
+}
+
+// FormDecoder decodes URL-encoded forms. Values are bound via the `in` struct tags, like InputFromRequest does for query arguments.
+type FormDecoder struct{}
+
+func (FormDecoder) Decode(r *http.Request, v any) error {
+	if err := r.ParseForm(); err != nil {
+		return errors.Join(ErrInvalidInput, err)
+	}
+
+	return bindForm(r, v)
+}
+
+// MultipartDecoder decodes multipart forms. Values are bound via the `in` struct tags, like InputFromRequest does for query arguments.
+// Up to MaxMemory bytes of files are kept in memory, the rest is stored in temporary files.
+type MultipartDecoder struct {
+	MaxMemory int64
+}
+
+func (d MultipartDecoder) Decode(r *http.Request, v any) error {
+	if err := r.ParseMultipartForm(d.MaxMemory); err != nil {
+		return errors.Join(ErrInvalidInput, err)
+	}
+
+	return bindForm(r, v)
+}
+
+// XMLDecoder decodes XML-encoded bodies.
+type XMLDecoder struct{}
+
+func (XMLDecoder) Decode(r *http.Request, v any) error {
+	return xml.NewDecoder(r.Body).Decode(v) //nolint:wrapcheck
+}
+
+// bindForm hydrates v, a pointer to a struct, from the parsed form values.
+func bindForm(r *http.Request, v any) error {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return errors.New("cannot decode a form into " + value.Type().String()) //nolint:err113
+	}
+
+	return bindRequest(value.Elem(), r, sourceForm)
+}
+
+// JSONDecoder decodes JSON-encoded bodies.
+// By default, unknown fields are ignored, numbers are decoded into float64 when v has no specific type for them,
+// and any data following the first JSON value is ignored.
+type JSONDecoder struct {
+	DisallowUnknownFields bool
+	RejectTrailingData    bool
+	UseNumber             bool
+}
+
+// Decode decodes the JSON-encoded body into v. Type mismatches and unknown fields are reported as FieldErrors.
+func (d JSONDecoder) Decode(r *http.Request, v any) error {
+	dec := json.NewDecoder(r.Body)
+
+	if d.DisallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+
+	if d.UseNumber {
+		dec.UseNumber()
+	}
+
+	if err := dec.Decode(v); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return FieldErrors{{Field: typeErr.Field, Source: SourceBody, Code: CodeInvalid, Message: err.Error()}}
+		}
+
+		// The json package has no dedicated error type for unknown fields.
+		if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			field, _ = strconv.Unquote(field)
+
+			return FieldErrors{{Field: field, Source: SourceBody, Code: CodeUnknown, Message: err.Error()}}
+		}
+
+		return err //nolint:wrapcheck
+	}
+
+	if d.RejectTrailingData {
+		if err := dec.Decode(&json.RawMessage{}); !errors.Is(err, io.EOF) {
+			// Bodies exceeding MaxBodyBytes are served with 413, whatever follows the JSON value.
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				return err //nolint:wrapcheck
+			}
+
+			return errors.Join(ErrInvalidInput, ErrTrailingData)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,219 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/luca-arch/go-goodies/handler"
+	"github.com/luca-arch/go-goodies/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Signup struct {
+	Email string   `in:"email,required" json:"email" xml:"email"`
+	Age   int      `in:"age,min=18"     json:"age"   xml:"age"`
+	Tags  []string `in:"tag"            json:"tags"  xml:"tag"`
+}
+
+// multipartBody returns a multipart form with the given fields, and one PNG file per field of files, named after its content.
+func multipartBody(t *testing.T, fields map[string]string, files map[string]string) (string, string) {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	mw := multipart.NewWriter(&buf)
+	for k, v := range fields {
+		require.NoError(t, mw.WriteField(k, v))
+	}
+
+	for field, content := range files {
+		part, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Disposition": {`form-data; name="` + field + `"; filename="` + content + `.png"`},
+			"Content-Type":        {"image/png"},
+		})
+		require.NoError(t, err)
+
+		_, err = io.WriteString(part, content)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, mw.Close())
+
+	return buf.String(), mw.FormDataContentType()
+}
+
+func TestDecoders(t *testing.T) {
+	t.Parallel()
+
+	h := handler.WithInputOutput(logger.NewNop(), func(_ context.Context, in Signup) (Signup, error) {
+		return in, nil
+	})
+
+	multipartData, multipartType := multipartBody(t, map[string]string{"email": "a@example.com", "age": "30"}, nil)
+
+	tests := map[string]struct {
+		body        string
+		contentType string
+		status      int
+		want        string
+	}{
+		"json without content type": {
+			body:   `{"email":"a@example.com","age":30,"tags":["x"]}`,
+			status: http.StatusOK,
+			want:   `{"email":"a@example.com","age":30,"tags":["x"]}`,
+		},
+		"json with charset": {
+			body:        `{"email":"a@example.com","age":30}`,
+			contentType: "application/json; charset=utf-8",
+			status:      http.StatusOK,
+			want:        `{"email":"a@example.com","age":30,"tags":null}`,
+		},
+		"form": {
+			body:        "email=a%40example.com&age=30&tag=x&tag=y",
+			contentType: "application/x-www-form-urlencoded",
+			status:      http.StatusOK,
+			want:        `{"email":"a@example.com","age":30,"tags":["x","y"]}`,
+		},
+		"invalid form": {
+			body:        "age=12",
+			contentType: "application/x-www-form-urlencoded",
+			status:      http.StatusBadRequest,
+			want:        `{"error":"invalid input\nmissing required field: email\nfield age must be at least 18","fields":[{"field":"email","source":"form","code":"required","message":"missing required field: email"},{"field":"age","source":"form","code":"min","message":"field age must be at least 18"}]}`,
+		},
+		"multipart": {
+			body:        multipartData,
+			contentType: multipartType,
+			status:      http.StatusOK,
+			want:        `{"email":"a@example.com","age":30,"tags":null}`,
+		},
+		"xml": {
+			body:        `<Signup><email>a@example.com</email><age>30</age><tag>x</tag></Signup>`,
+			contentType: "application/xml",
+			status:      http.StatusOK,
+			want:        `{"email":"a@example.com","age":30,"tags":["x"]}`,
+		},
+		"unsupported media type": {
+			body:        "email: a@example.com",
+			contentType: "application/yaml",
+			status:      http.StatusUnsupportedMediaType,
+			want:        `{"error":"unsupported media type\napplication/yaml"}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.body))
+			if test.contentType != "" {
+				r.Header.Set("Content-Type", test.contentType)
+			}
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			assert.Equal(t, test.status, w.Code)
+			assert.JSONEq(t, test.want, w.Body.String())
+		})
+	}
+}
+
+func TestStrictJSON(t *testing.T) {
+	t.Parallel()
+
+	echo := func(_ context.Context, in map[string]any) (map[string]any, error) {
+		return in, nil
+	}
+
+	strict := handler.WithInputOutput(logger.NewNop(), func(_ context.Context, in Signup) (Signup, error) {
+		return in, nil
+	}, handler.DisallowUnknownFields(), handler.RejectTrailingData(), handler.MaxBodyBytes(64))
+
+	tests := map[string]struct {
+		handler http.Handler
+		body    string
+		status  int
+		want    string
+	}{
+		"lax by default": {
+			handler: handler.WithInputOutput(logger.NewNop(), echo),
+			body:    `{"n":12345678901234567890} trailing`,
+			status:  http.StatusOK,
+			want:    `{"n":12345678901234567000}`,
+		},
+		"bare decoder": {
+			handler: handler.WithInputOutput(logger.NewNop(), func(_ context.Context, in Signup) (Signup, error) {
+				return in, nil
+			}, handler.Decoder(handler.JSONDecoder{UseNumber: true}), handler.DisallowUnknownFields()),
+			body:   `{"email":"a@example.com","agee":30}`,
+			status: http.StatusBadRequest,
+			want:   `{"error":"json: unknown field \"agee\"","fields":[{"field":"agee","source":"body","code":"unknown","message":"json: unknown field \"agee\""}]}`,
+		},
+		"use number": {
+			handler: handler.WithInputOutput(logger.NewNop(), echo, handler.UseNumber()),
+			body:    `{"n":12345678901234567890}`,
+			status:  http.StatusOK,
+			want:    `{"n":12345678901234567890}`,
+		},
+		"strict": {
+			handler: strict,
+			body:    `{"email":"a@example.com","age":30}`,
+			status:  http.StatusOK,
+			want:    `{"email":"a@example.com","age":30,"tags":null}`,
+		},
+		"unknown field": {
+			handler: strict,
+			body:    `{"email":"a@example.com","agee":30}`,
+			status:  http.StatusBadRequest,
+			want:    `{"error":"json: unknown field \"agee\"","fields":[{"field":"agee","source":"body","code":"unknown","message":"json: unknown field \"agee\""}]}`,
+		},
+		"trailing data": {
+			handler: strict,
+			body:    `{"email":"a@example.com"} {"email":"b@example.com"}`,
+			status:  http.StatusBadRequest,
+			want:    `{"error":"invalid input\nunexpected data after the JSON body"}`,
+		},
+		"trailing data too large": {
+			handler: strict,
+			body:    `{"email":"a@example.com"}` + strings.Repeat(" ", 64) + `{}`,
+			status:  http.StatusRequestEntityTooLarge,
+			want:    `{"error":"http: request body too large"}`,
+		},
+		"body too large": {
+			handler: strict,
+			body:    `{"email":"` + strings.Repeat("a", 64) + `@example.com"}`,
+			status:  http.StatusRequestEntityTooLarge,
+			want:    `{"error":"http: request body too large"}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			test.handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.body)))
+
+			assert.Equal(t, test.status, w.Code)
+			assert.JSONEq(t, test.want, w.Body.String())
+		})
+	}
+}
+
+func TestStrictJSONNeedsJSONDecoder(t *testing.T) {
+	t.Parallel()
+
+	assert.PanicsWithValue(t, "handler: the JSON options need a JSONDecoder or Decoders, not handler.XMLDecoder", func() {
+		handler.WithInputOutput(logger.NewNop(), func(_ context.Context, in Signup) (Signup, error) {
+			return in, nil
+		}, handler.Decoder(handler.XMLDecoder{}), handler.RejectTrailingData())
+	})
+}
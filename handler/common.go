@@ -3,50 +3,377 @@
 package handler
 
 import (
-	"encoding/json"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
-	"log/slog"
 	"net/http"
+
+	"github.com/luca-arch/go-goodies/handler/middleware"
+	"github.com/luca-arch/go-goodies/logger"
 )
 
 var (
 	ErrInvalidArg   = errors.New("invalid query argument")
 	ErrInvalidInput = errors.New("invalid input")
+	ErrEncode       = errors.New("cannot encode the response")
+	ErrPanic        = errors.New("internal error")
 	success         = &SuccessResponse{V: true} //nolint:gochecknoglobals
 )
 
+// ErrResponse is the body of the error responses.
+// Fields lists every invalid field when the error was caused by the request's input.
+// CorrelationID is set in safe mode, when the error message has been replaced with a generic one.
 type ErrResponse struct {
-	Error string `json:"error"`
+	Error         string      `json:"error"                   xml:"error"`
+	Fields        FieldErrors `json:"fields,omitempty"        xml:"fields>field,omitempty"`
+	CorrelationID string      `json:"correlationId,omitempty" xml:"correlationId,omitempty"`
 }
 
 type SuccessResponse struct {
-	V bool `json:"success"`
+	V bool `json:"success" xml:"success"`
+}
+
+// readArgs reads the request's arguments, and checks them against the validators of the handler.
+func readArgs[T any](r *http.Request, cfg *config) (T, error) { //nolint:ireturn
+	args, err := InputFromRequest[T](r)
+	if err != nil {
+		return args, err
+	}
+
+	return args, runValidators(cfg, args)
 }
 
-func writeErrResponse(w http.ResponseWriter, err error, status int) error {
-	w.Header().Set("Content-Type", "application/json")
+// readBody decodes the request's body and checks its validation rules.
+// Bodies exceeding the configured size are rejected with 413.
+func readBody[T any](w http.ResponseWriter, r *http.Request, cfg *config) (T, error) { //nolint:ireturn
+	var in T
+
+	if cfg.maxBodyBytes > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, cfg.maxBodyBytes)
+	}
+
+	decode := cfg.decoder.Decode
+	if reader, ok := any(&in).(bodyReader); ok {
+		decode = func(r *http.Request, _ any) error { return reader.readBody(w, r, cfg) }
+	}
+
+	if err := decode(r, &in); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return in, NewStatusError(http.StatusRequestEntityTooLarge, err)
+		}
+
+		return in, err //nolint:wrapcheck
+	}
+
+	if err := Validate(in); err != nil {
+		return in, errors.Join(ErrInvalidInput, err)
+	}
+
+	return in, runValidators(cfg, in)
+}
+
+// runValidators calls the validators of the handler.
+func runValidators(cfg *config, v any) error {
+	for _, validate := range cfg.validators {
+		if err := validate(v); err != nil {
+			return errors.Join(ErrInvalidInput, err)
+		}
+	}
+
+	return nil
+}
+
+// writeInputError writes an error that occurred while reading the request.
+// These are served with 400, unless the error chooses its own status.
+func writeInputError(w http.ResponseWriter, r *http.Request, cfg *config, enc ResponseEncoder, err error) {
+	status := http.StatusBadRequest
+
+	var coder StatusCoder
+	if errors.As(err, &coder) && coder.StatusCode() != 0 {
+		status = coder.StatusCode()
+	}
+
+	if wErr := writeErrResponse(w, r, cfg, enc, err, status); wErr != nil {
+		cfg.logger.Warn("failed to serve HTTP response", "error", wErr)
+	}
+}
+
+// writeErrResponse writes the error either as an ErrResponse or, if configured so, as problem details.
+// The negotiated encoder is used, unless it cannot encode errors.
+func writeErrResponse(w http.ResponseWriter, r *http.Request, cfg *config, enc ResponseEncoder, err error, status int) error {
+	body := errorBody(r, cfg, err, status)
+
+	if !canEncode(enc, body) {
+		enc = cfg.encoders.Default()
+	}
+
+	contentType := enc.ContentType()
+	if cfg.problemDetails {
+		contentType = problemContentType(enc)
+	}
+
+	w.Header().Set("Content-Type", contentType)
 	w.WriteHeader(status)
 
-	return json.NewEncoder(w).Encode(&ErrResponse{Error: err.Error()}) //nolint:wrapcheck
+	return enc.Encode(w, body) //nolint:wrapcheck
 }
 
-// writeResponse is an helper that writes JSON-encoded data into the ResponseWriter.
-func writeResponse[T any](w http.ResponseWriter, logger *slog.Logger, out T, err error) {
-	w.Header().Set("Content-Type", "application/json")
+// errorBody returns the body the error is served with: an ErrResponse or, if configured so, problem details.
+func errorBody(r *http.Request, cfg *config, err error, status int) any {
+	if cfg.problemDetails {
+		return newProblem(r, err, status)
+	}
 
-	var wErr error
+	resp := &ErrResponse{Error: err.Error(), Fields: nil, CorrelationID: ""}
 
-	switch {
-	case err == nil:
-		w.WriteHeader(http.StatusOK)
-		wErr = json.NewEncoder(w).Encode(out)
-	case errors.Is(err, ErrInvalidInput), errors.Is(err, ErrInvalidArg):
-		wErr = writeErrResponse(w, err, http.StatusBadRequest)
+	// Expose every invalid field, so that clients can highlight them at once.
+	errors.As(err, &resp.Fields)
+
+	var masked *maskedError
+	if errors.As(err, &masked) {
+		resp.CorrelationID = masked.correlationID
+	}
+
+	return resp
+}
+
+// newProblem returns the RFC 9457 problem details of the error.
+// The details attached to err via Problem are preserved, while missing members are filled from the request and status.
+func newProblem(r *http.Request, err error, status int) *Problem {
+	problem := &Problem{
+		Type:       "",
+		Title:      http.StatusText(status),
+		Status:     status,
+		Detail:     err.Error(),
+		Instance:   r.URL.Path,
+		Extensions: map[string]any{},
+		Err:        err,
+	}
+
+	var custom *Problem
+	if errors.As(err, &custom) {
+		problem.Type = custom.Type
+
+		if custom.Title != "" {
+			problem.Title = custom.Title
+		}
+
+		if custom.Instance != "" {
+			problem.Instance = custom.Instance
+		}
+
+		for k, v := range custom.Extensions {
+			problem.Extensions[k] = v
+		}
+	}
+
+	var fieldErrs FieldErrors
+	if errors.As(err, &fieldErrs) {
+		problem.Extensions["errors"] = fieldErrs
+	}
+
+	var masked *maskedError
+	if errors.As(err, &masked) {
+		problem.Extensions["correlationId"] = masked.correlationID
+	}
+
+	return problem
+}
+
+// problemContentType returns the content type of the problem details encoded by enc.
+func problemContentType(enc ResponseEncoder) string {
+	switch enc.ContentType() {
+	case MediaTypeJSON, MediaTypeNDJSON:
+		return "application/problem+json"
+	case MediaTypeXML:
+		return "application/problem+xml"
 	default:
-		wErr = writeErrResponse(w, err, http.StatusInternalServerError)
+		return enc.ContentType()
+	}
+}
+
+// writeResponse is an helper that writes encoded data into the ResponseWriter.
+func writeResponse[T any](w http.ResponseWriter, r *http.Request, cfg *config, enc ResponseEncoder, out T, err error) {
+	var wErr error
+
+	if err == nil {
+		wErr = writeOutput(w, r, cfg, enc, out)
+	} else {
+		var headerer Headerer
+		if errors.As(err, &headerer) {
+			for k, v := range headerer.Header() {
+				w.Header()[k] = v
+			}
+		}
+
+		status, exposed := exposeError(r, cfg, err)
+
+		wErr = writeErrResponse(w, r, cfg, enc, exposed, status)
 	}
 
 	if wErr != nil {
-		logger.Warn("failed to serve HTTP response", "error", wErr)
+		cfg.logger.Warn("failed to serve HTTP response", "error", wErr)
 	}
 }
+
+// exposeError returns the status of the error, along with the error that can be served to the client.
+// In safe mode, internal errors are logged and masked. Errors mapped with HideMessage only expose their status text.
+func exposeError(r *http.Request, cfg *config, err error) (int, error) {
+	var public *publicError
+
+	status, exposure := errorStatus(cfg, err)
+
+	switch {
+	case cfg.safeErrors && status >= http.StatusInternalServerError && !errors.As(err, &public):
+		masked := &maskedError{status: status, correlationID: correlationID(r)}
+
+		cfg.logger.Error("HTTP handler failed",
+			"error", err,
+			"correlation_id", masked.correlationID,
+			"http.url", r.URL,
+		)
+
+		return status, masked
+	case exposure == HideMessage:
+		return status, errors.New(http.StatusText(status)) //nolint:err113
+	}
+
+	return status, err
+}
+
+// writeOutput writes the encoded output, honouring the status, headers and body it may choose for itself.
+// Downloads are served as they are, and nil ones with 500. If the negotiated encoder cannot encode the output, the next acceptable one is used,
+// and 406 is served if there is none. The output is encoded before anything is written, so that failures are served with 500.
+func writeOutput(w http.ResponseWriter, r *http.Request, cfg *config, enc ResponseEncoder, out any) error {
+	status := http.StatusOK
+
+	if coder, ok := out.(StatusCoder); ok && coder.StatusCode() != 0 {
+		status = coder.StatusCode()
+	}
+
+	setHeaders := func() {
+		if headerer, ok := out.(Headerer); ok {
+			for k, v := range headerer.Header() {
+				w.Header()[k] = v
+			}
+		}
+	}
+
+	body := out
+	if bodier, ok := out.(Bodier); ok {
+		body = bodier.ResponseBody()
+	}
+
+	if download, ok := body.(*Download); ok {
+		if download == nil {
+			cfg.logger.Error("failed to serve HTTP response", "error", ErrNilDownload)
+
+			return writeErrResponse(w, r, cfg, cfg.encoders.Default(), ErrNilDownload, http.StatusInternalServerError)
+		}
+
+		setHeaders()
+		serveDownload(w, r, download)
+
+		return nil
+	}
+
+	if !bodyAllowed(status) {
+		setHeaders()
+		w.WriteHeader(status)
+
+		return nil
+	}
+
+	// Fall back to the next format the client accepts, if the negotiated one cannot encode the output.
+	if !canEncode(enc, body) {
+		next, ok := cfg.encoders.NegotiateFor(r.Header.Get("Accept"), body)
+		if !ok {
+			return writeErrResponse(w, r, cfg, cfg.encoders.Default(), errNotAcceptable(enc), http.StatusNotAcceptable)
+		}
+
+		enc = next
+	}
+
+	var buf bytes.Buffer
+
+	if err := enc.Encode(&buf, body); err != nil {
+		cfg.logger.Error("failed to encode HTTP response", "error", err, "content_type", enc.ContentType())
+
+		return writeErrResponse(w, r, cfg, cfg.encoders.Default(), ErrEncode, http.StatusInternalServerError)
+	}
+
+	setHeaders()
+	w.Header().Set("Content-Type", enc.ContentType())
+	w.WriteHeader(status)
+
+	_, err := w.Write(buf.Bytes())
+
+	return err //nolint:wrapcheck
+}
+
+// errNotAcceptable returns the error served when the response cannot be encoded in any of the formats accepted by the client.
+func errNotAcceptable(enc ResponseEncoder) error {
+	if enc == nil {
+		return NewStatusError(http.StatusNotAcceptable, ErrNotAcceptable)
+	}
+
+	return NewStatusError(http.StatusNotAcceptable, errors.Join(ErrNotAcceptable, errors.New("cannot encode the response to "+enc.ContentType()))) //nolint:err113
+}
+
+// correlationID returns the request ID sent by the client or a proxy, or a new random one if it is missing or invalid.
+func correlationID(r *http.Request) string {
+	if id := r.Header.Get(middleware.HeaderRequestID); middleware.ValidRequestID(id) {
+		return id
+	}
+
+	b := make([]byte, 8) //nolint:mnd // 64 bits are enough to find a log entry.
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+// errorStatus returns the HTTP status an error is served with, and whether its message can be exposed.
+// Errors implementing StatusCoder choose their own, then the error mapper is consulted.
+// Failing that, invalid inputs are served with 400 and everything else with 500.
+func errorStatus(cfg *config, err error) (int, Exposure) {
+	var coder StatusCoder
+
+	if errors.As(err, &coder) && coder.StatusCode() != 0 {
+		return coder.StatusCode(), ExposeMessage
+	}
+
+	if status, exposure, ok := cfg.mapper.Lookup(err); ok {
+		return status, exposure
+	}
+
+	if errors.Is(err, ErrInvalidInput) || errors.Is(err, ErrInvalidArg) {
+		return http.StatusBadRequest, ExposeMessage
+	}
+
+	return http.StatusInternalServerError, ExposeMessage
+}
+
+// withRequestLogger returns copies of the request and of the settings, both carrying a logger with the request's attributes.
+// The logger is stored in the request's context, so that the handler's function can retrieve it via logger.FromContext.
+// A logger already carried by the context (eg. set by a middleware) is preferred to the one of the settings.
+// The request ID is logged only if it is valid, see middleware.ValidRequestID.
+func withRequestLogger(r *http.Request, cfg *config) (*http.Request, *config) {
+	attrs := []any{"http.method", r.Method}
+
+	if r.Pattern != "" {
+		attrs = append(attrs, "http.route", r.Pattern)
+	}
+
+	if id := r.Header.Get(middleware.HeaderRequestID); middleware.ValidRequestID(id) {
+		attrs = append(attrs, "request_id", id)
+	}
+
+	l := logger.FromContextOr(r.Context(), cfg.logger).With(attrs...)
+
+	scoped := *cfg
+	scoped.logger = l
+
+	return r.WithContext(logger.WithContext(r.Context(), l)), &scoped
+}
@@ -0,0 +1,439 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/luca-arch/go-goodies/handler"
+	"github.com/luca-arch/go-goodies/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type CreateArgs struct {
+	Tenant string `in:"X-Tenant-Id,header,required"`
+}
+
+type CreateInput struct {
+	Name  string `json:"name"  validate:"required"`
+	Count int    `json:"count" validate:"min=1"`
+}
+
+func TestErrResponse(t *testing.T) {
+	t.Parallel()
+
+	h := handler.With(logger.NewNop(), func(_ context.Context, in CreateInput, _ CreateArgs) (CreateInput, error) {
+		return in, nil
+	})
+
+	tests := map[string]struct {
+		body    string
+		headers map[string]string
+		status  int
+		want    handler.ErrResponse
+	}{
+		"invalid args": {
+			body:   `{}`,
+			status: http.StatusBadRequest,
+			want: handler.ErrResponse{
+				Error: "invalid input\nmissing required field: X-Tenant-Id",
+				Fields: handler.FieldErrors{
+					{Field: "X-Tenant-Id", Source: "header", Code: "required", Message: "missing required field: X-Tenant-Id"},
+				},
+			},
+		},
+		"invalid body": {
+			body:    `{"count": 0}`,
+			headers: map[string]string{"X-Tenant-Id": "acme"},
+			status:  http.StatusBadRequest,
+			want: handler.ErrResponse{
+				Error: "invalid input\nmissing required field: name\nfield count must be at least 1",
+				Fields: handler.FieldErrors{
+					{Field: "name", Source: "body", Code: "required", Message: "missing required field: name"},
+					{Field: "count", Source: "body", Code: "min", Message: "field count must be at least 1"},
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.body))
+			for k, v := range test.headers {
+				r.Header.Set(k, v)
+			}
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			var got handler.ErrResponse
+
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+			assert.Equal(t, test.status, w.Code)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+var errOutOfCredit = errors.New("your current balance is 30, but that costs 50")
+
+func TestProblemDetails(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		handler http.Handler
+		url     string
+		status  int
+		want    map[string]any
+	}{
+		"custom problem": {
+			handler: handler.WithOutput(logger.NewNop(), func(_ context.Context) (any, error) {
+				problem := handler.NewProblem(errOutOfCredit, "https://example.com/probs/out-of-credit", map[string]any{"balance": 30})
+				problem.Title = "You do not have enough credit."
+				problem.Status = http.StatusForbidden
+
+				return nil, problem
+			}, handler.ProblemDetails()),
+			url:    "/account/12345/msgs/abc",
+			status: http.StatusForbidden,
+			want: map[string]any{
+				"type":     "https://example.com/probs/out-of-credit",
+				"title":    "You do not have enough credit.",
+				"status":   float64(http.StatusForbidden),
+				"detail":   "your current balance is 30, but that costs 50",
+				"instance": "/account/12345/msgs/abc",
+				"balance":  float64(30),
+			},
+		},
+		"invalid input": {
+			handler: handler.WithArgs(logger.NewNop(), func(_ context.Context, _ CreateArgs) error {
+				return nil
+			}, handler.ProblemDetails()),
+			url:    "/items",
+			status: http.StatusBadRequest,
+			want: map[string]any{
+				"type":     "about:blank",
+				"title":    "Bad Request",
+				"status":   float64(http.StatusBadRequest),
+				"detail":   "invalid input\nmissing required field: X-Tenant-Id",
+				"instance": "/items",
+				"errors": []any{
+					map[string]any{"field": "X-Tenant-Id", "source": "header", "code": "required", "message": "missing required field: X-Tenant-Id"},
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			test.handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.url, nil))
+
+			var got map[string]any
+
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+			assert.Equal(t, test.status, w.Code)
+			assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestStatusError(t *testing.T) {
+	t.Parallel()
+
+	errNotFound := errors.New("item not found")
+
+	tests := map[string]struct {
+		err     error
+		status  int
+		headers map[string]string
+		want    string
+	}{
+		"not found": {
+			err:    handler.NotFound(errNotFound),
+			status: http.StatusNotFound,
+			want:   "item not found",
+		},
+		"wrapped conflict": {
+			err:    fmt.Errorf("saving item: %w", handler.Conflict(errors.New("duplicate name"))),
+			status: http.StatusConflict,
+			want:   "saving item: duplicate name",
+		},
+		"too many requests": {
+			err:     handler.TooManyRequests(nil, 30*time.Second),
+			status:  http.StatusTooManyRequests,
+			headers: map[string]string{"Retry-After": "30"},
+			want:    "Too Many Requests",
+		},
+		"custom status and header": {
+			err:     handler.NewStatusError(http.StatusTeapot, errors.New("short and stout")).SetHeader("X-Kettle", "on"),
+			status:  http.StatusTeapot,
+			headers: map[string]string{"X-Kettle": "on"},
+			want:    "short and stout",
+		},
+		"unknown error": {
+			err:    errNotFound,
+			status: http.StatusInternalServerError,
+			want:   "item not found",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			h := handler.WithArgsOutput(logger.NewNop(), func(_ context.Context, _ struct{}) (any, error) {
+				return nil, test.err
+			})
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			var got handler.ErrResponse
+
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+			assert.Equal(t, test.status, w.Code)
+			assert.Equal(t, test.want, got.Error)
+
+			for k, v := range test.headers {
+				assert.Equal(t, v, w.Header().Get(k))
+			}
+		})
+	}
+}
+
+func TestErrorMapping(t *testing.T) {
+	t.Parallel()
+
+	var (
+		errNoRows   = errors.New("no rows in result set")
+		errInternal = errors.New(`duplicate key value violates unique constraint "items_name_key"`)
+	)
+
+	mapper := handler.NewErrorMapper().
+		Map(errNoRows, http.StatusNotFound, handler.ExposeMessage).
+		MapFunc(func(err error) bool { return errors.Is(err, errInternal) }, http.StatusConflict, handler.HideMessage).
+		Map(context.DeadlineExceeded, http.StatusGatewayTimeout, handler.HideMessage)
+
+	tests := map[string]struct {
+		err    error
+		status int
+		want   string
+	}{
+		"exposed message": {
+			err:    errors.Join(errors.New("select (one row) error"), errNoRows),
+			status: http.StatusNotFound,
+			want:   "select (one row) error\nno rows in result set",
+		},
+		"hidden message": {
+			err:    fmt.Errorf("insert: %w", errInternal),
+			status: http.StatusConflict,
+			want:   "Conflict",
+		},
+		"context error": {
+			err:    context.DeadlineExceeded,
+			status: http.StatusGatewayTimeout,
+			want:   "Gateway Timeout",
+		},
+		"typed errors win": {
+			err:    handler.Forbidden(errNoRows),
+			status: http.StatusForbidden,
+			want:   "no rows in result set",
+		},
+		"unmapped error": {
+			err:    errors.New("boom"),
+			status: http.StatusInternalServerError,
+			want:   "boom",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			h := handler.WithOutput(logger.NewNop(), func(_ context.Context) (any, error) {
+				return nil, test.err
+			}, handler.ErrorMapping(mapper))
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			var got handler.ErrResponse
+
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+			assert.Equal(t, test.status, w.Code)
+			assert.Equal(t, test.want, got.Error)
+		})
+	}
+}
+
+func TestSafeErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		err       error
+		requestID string
+		status    int
+		want      handler.ErrResponse
+		wantLog   bool
+	}{
+		"internal error": {
+			err:       errors.New("relation items does not exist"),
+			requestID: "req-1",
+			status:    http.StatusInternalServerError,
+			want:      handler.ErrResponse{Error: "Internal Server Error", CorrelationID: "req-1"},
+			wantLog:   true,
+		},
+		"invalid request ID": {
+			err:       errors.New("relation items does not exist"),
+			requestID: `req-1"} {"level":"INFO"`,
+			status:    http.StatusInternalServerError,
+			want:      handler.ErrResponse{Error: "Internal Server Error"},
+			wantLog:   true,
+		},
+		"public internal error": {
+			err:    handler.Public(errors.New("maintenance in progress")),
+			status: http.StatusInternalServerError,
+			want:   handler.ErrResponse{Error: "maintenance in progress"},
+		},
+		"client error": {
+			err:    handler.NotFound(errors.New("item not found")),
+			status: http.StatusNotFound,
+			want:   handler.ErrResponse{Error: "item not found"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var logs bytes.Buffer
+
+			h := handler.WithOutput(slog.New(slog.NewJSONHandler(&logs, nil)), func(_ context.Context) (any, error) {
+				return nil, test.err
+			}, handler.SafeErrors())
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.requestID != "" {
+				r.Header.Set("X-Request-Id", test.requestID)
+			}
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			var got handler.ErrResponse
+
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+			assert.Equal(t, test.status, w.Code)
+
+			// Invalid request IDs are replaced by a random one.
+			if test.wantLog && test.want.CorrelationID == "" {
+				assert.Len(t, got.CorrelationID, 16)
+				test.want.CorrelationID = got.CorrelationID
+			}
+
+			assert.Equal(t, test.want, got)
+
+			if test.wantLog {
+				assert.Contains(t, logs.String(), `"correlation_id":"`+test.want.CorrelationID+`"`)
+				assert.Contains(t, logs.String(), test.err.Error())
+			} else {
+				assert.Empty(t, logs.String())
+			}
+		})
+	}
+}
+
+// Pending is an output choosing its own status, which is left to the default one.
+type Pending struct {
+	ID int `json:"id"`
+}
+
+func (Pending) StatusCode() int {
+	return 0
+}
+
+func TestResponse(t *testing.T) {
+	t.Parallel()
+
+	type item struct {
+		ID int `json:"id"`
+	}
+
+	tests := map[string]struct {
+		handler http.Handler
+		status  int
+		headers map[string]string
+		body    string
+	}{
+		"created": {
+			handler: handler.WithInputOutput(logger.NewNop(), func(_ context.Context, in item) (*handler.Response[item], error) {
+				return handler.Created("/items/"+strconv.Itoa(in.ID), in), nil
+			}),
+			status:  http.StatusCreated,
+			headers: map[string]string{"Location": "/items/7", "Content-Type": "application/json"},
+			body:    `{"id":7}` + "\n",
+		},
+		"accepted": {
+			handler: handler.WithInputOutput(logger.NewNop(), func(_ context.Context, in item) (*handler.Response[item], error) {
+				return handler.Accepted(in).SetHeader("X-Job", "1"), nil
+			}),
+			status:  http.StatusAccepted,
+			headers: map[string]string{"X-Job": "1"},
+			body:    `{"id":7}` + "\n",
+		},
+		"no content": {
+			handler: handler.WithArgsOutput(logger.NewNop(), func(_ context.Context, _ struct{}) (*handler.Response[struct{}], error) {
+				return handler.NoContent(), nil
+			}),
+			status:  http.StatusNoContent,
+			headers: map[string]string{"Content-Type": ""},
+			body:    "",
+		},
+		"zero status": {
+			handler: handler.WithInputOutput(logger.NewNop(), func(_ context.Context, in item) (Pending, error) {
+				return Pending{ID: in.ID}, nil
+			}),
+			status: http.StatusOK,
+			body:   `{"id":7}` + "\n",
+		},
+		"plain output": {
+			handler: handler.WithInputOutput(logger.NewNop(), func(_ context.Context, in item) (item, error) {
+				return in, nil
+			}),
+			status: http.StatusOK,
+			body:   `{"id":7}` + "\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			test.handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(`{"id":7}`)))
+
+			assert.Equal(t, test.status, w.Code)
+			assert.Equal(t, test.body, w.Body.String())
+
+			for k, v := range test.headers {
+				assert.Equal(t, v, w.Header().Get(k))
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"time"
+)
+
+var (
+	ErrNotSeekable = errors.New("file is not seekable")
+	ErrNilDownload = errors.New("no download to serve")
+)
+
+// Download is an output served as a file rather than encoded, eg. a report or an attachment.
+// Range requests (206 Partial Content) and conditional requests (If-Modified-Since) are handled as by http.ServeContent.
+// The Content-Type is guessed from the name or the content when left empty, and ModTime is optional.
+// Content is closed once served, if it implements io.Closer.
+type Download struct {
+	Content     io.ReadSeeker
+	Name        string
+	ContentType string
+	ModTime     time.Time
+	Inline      bool
+}
+
+// NewDownload returns a download of the given content, offered to the client as an attachment named name.
+func NewDownload(content io.ReadSeeker, name, contentType string) *Download {
+	return &Download{Content: content, Name: name, ContentType: contentType, ModTime: time.Time{}, Inline: false}
+}
+
+// FileDownload returns a download of the file, named and dated after it. The file must implement io.Seeker, as os.File does.
+func FileDownload(f fs.File) (*Download, error) {
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		return nil, ErrNotSeekable
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	return &Download{Content: content, Name: info.Name(), ContentType: "", ModTime: info.ModTime(), Inline: false}, nil
+}
+
+// serveDownload writes the download, along with the Content-Disposition that names it.
+func serveDownload(w http.ResponseWriter, r *http.Request, d *Download) {
+	if closer, ok := d.Content.(io.Closer); ok {
+		defer func() { _ = closer.Close() }()
+	}
+
+	disposition := "attachment"
+	if d.Inline {
+		disposition = "inline"
+	}
+
+	if d.Name != "" {
+		disposition = mime.FormatMediaType(disposition, map[string]string{"filename": d.Name})
+	}
+
+	w.Header().Set("Content-Disposition", disposition)
+
+	if d.ContentType != "" {
+		w.Header().Set("Content-Type", d.ContentType)
+	}
+
+	http.ServeContent(w, r, d.Name, d.ModTime, d.Content)
+}
@@ -0,0 +1,154 @@
+package handler_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/luca-arch/go-goodies/handler"
+	"github.com/luca-arch/go-goodies/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+type ReportArgs struct {
+	Name string `in:"name,required"`
+}
+
+func TestDownload(t *testing.T) {
+	t.Parallel()
+
+	modTime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	reports := fstest.MapFS{
+		"sales.csv":  {Data: []byte("month,total\nmay,100\n"), Mode: 0, ModTime: modTime, Sys: nil},
+		"ventes.csv": {Data: []byte("mois,total\n"), Mode: 0, ModTime: modTime, Sys: nil},
+	}
+
+	h := handler.WithArgsOutput(logger.NewNop(), func(_ context.Context, args ReportArgs) (*handler.Download, error) {
+		if args.Name == "none.csv" {
+			return nil, nil //nolint:nilnil // Served with 500.
+		}
+
+		f, err := reports.Open(args.Name)
+		if err != nil {
+			return nil, handler.NotFound(err)
+		}
+
+		download, err := handler.FileDownload(f)
+		if err != nil {
+			return nil, err
+		}
+
+		if args.Name == "ventes.csv" {
+			download.Name = "rapport d'été.csv"
+		}
+
+		return download, nil
+	})
+
+	tests := map[string]struct {
+		target  string
+		header  http.Header
+		status  int
+		headers map[string]string
+		want    string
+	}{
+		"full": {
+			target: "/?name=sales.csv",
+			header: http.Header{"Accept": {"text/csv"}},
+			status: http.StatusOK,
+			headers: map[string]string{
+				"Content-Disposition": `attachment; filename=sales.csv`,
+				"Content-Length":      "20",
+				"Content-Type":        "text/csv; charset=utf-8",
+				"Last-Modified":       "Wed, 01 May 2024 12:00:00 GMT",
+			},
+			want: "month,total\nmay,100\n",
+		},
+		"range": {
+			target: "/?name=sales.csv",
+			header: http.Header{"Range": {"bytes=12-"}},
+			status: http.StatusPartialContent,
+			headers: map[string]string{
+				"Content-Length": "8",
+				"Content-Range":  "bytes 12-19/20",
+			},
+			want: "may,100\n",
+		},
+		"not modified": {
+			target:  "/?name=sales.csv",
+			header:  http.Header{"If-Modified-Since": {"Wed, 01 May 2024 12:00:00 GMT"}},
+			status:  http.StatusNotModified,
+			headers: map[string]string{"Content-Length": ""},
+			want:    "",
+		},
+		"any accepted type": {
+			target:  "/?name=ventes.csv",
+			header:  http.Header{"Accept": {"application/pdf"}},
+			status:  http.StatusOK,
+			headers: map[string]string{"Content-Disposition": `attachment; filename*=utf-8''rapport%20d%27%C3%A9t%C3%A9.csv`},
+			want:    "mois,total\n",
+		},
+		"error": {
+			target:  "/?name=missing.csv",
+			header:  http.Header{"Accept": {"application/pdf"}},
+			status:  http.StatusNotFound,
+			headers: map[string]string{"Content-Type": "application/json"},
+			want:    `{"error":"open missing.csv: file does not exist"}` + "\n",
+		},
+		"nil download": {
+			target:  "/?name=none.csv",
+			header:  http.Header{"Accept": {"text/csv"}},
+			status:  http.StatusInternalServerError,
+			headers: map[string]string{"Content-Type": "application/json"},
+			want:    `{"error":"no download to serve"}` + "\n",
+		},
+	}
+
+	t.Run("response", func(t *testing.T) {
+		t.Parallel()
+
+		h := handler.WithOutput(logger.NewNop(), func(_ context.Context) (*handler.Response[*handler.Download], error) {
+			f, err := reports.Open("sales.csv")
+			if err != nil {
+				return nil, err
+			}
+
+			download, err := handler.FileDownload(f)
+
+			return handler.NewResponse(http.StatusOK, download).SetHeader("Cache-Control", "no-store"), err
+		})
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", "text/csv")
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+		assert.Equal(t, "month,total\nmay,100\n", w.Body.String())
+	})
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, test.target, nil)
+			r.Header = test.header
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			assert.Equal(t, test.status, w.Code)
+			assert.Equal(t, test.want, w.Body.String())
+
+			for k, v := range test.headers {
+				assert.Equal(t, v, w.Header().Get(k), k)
+			}
+		})
+	}
+}
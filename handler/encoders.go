@@ -0,0 +1,525 @@
+package handler
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Media types of the responses encoded out of the box.
+const (
+	MediaTypeCSV     = "text/csv"
+	MediaTypeMsgPack = "application/msgpack"
+	MediaTypeNDJSON  = "application/x-ndjson"
+)
+
+var (
+	ErrNotAcceptable = errors.New("not acceptable")
+
+	// DefaultEncoders is the registry the response's format is negotiated from, by the handlers that don't use the Encoder option.
+	DefaultEncoders = NewEncoders(JSONEncoder{}, NDJSONEncoder{}, CSVEncoder{}, XMLEncoder{}, MsgPackEncoder{}) //nolint:gochecknoglobals
+)
+
+// ResponseEncoder encodes values into the response's body.
+// Encoders that can only encode some values (eg. CSVEncoder) can implement `CanEncode(v any) bool`.
+type ResponseEncoder interface {
+	ContentType() string
+	Encode(w io.Writer, v any) error
+}
+
+type canEncoder interface {
+	CanEncode(v any) bool
+}
+
+// Encoders picks the ResponseEncoder from the request's Accept header.
+// The first encoder is used when the client accepts anything, and for the values the negotiated encoder cannot encode.
+type Encoders struct {
+	mu       sync.RWMutex
+	encoders []ResponseEncoder
+}
+
+// NewEncoders returns a registry of the given encoders.
+func NewEncoders(encs ...ResponseEncoder) *Encoders {
+	e := &Encoders{
+		mu:       sync.RWMutex{},
+		encoders: nil,
+	}
+
+	for _, enc := range encs {
+		e.Register(enc)
+	}
+
+	return e
+}
+
+// RegisterEncoder registers a ResponseEncoder on the DefaultEncoders.
+func RegisterEncoder(enc ResponseEncoder) {
+	DefaultEncoders.Register(enc)
+}
+
+// Register adds an encoder, replacing the one registered for the same content type if any.
+func (e *Encoders) Register(enc ResponseEncoder) *Encoders {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	idx := slices.IndexFunc(e.encoders, func(other ResponseEncoder) bool {
+		return other.ContentType() == enc.ContentType()
+	})
+
+	if idx >= 0 {
+		e.encoders[idx] = enc
+	} else {
+		e.encoders = append(e.encoders, enc)
+	}
+
+	return e
+}
+
+// Default returns the first registered encoder.
+func (e *Encoders) Default() ResponseEncoder { //nolint:ireturn
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if len(e.encoders) == 0 {
+		return JSONEncoder{}
+	}
+
+	return e.encoders[0]
+}
+
+// Negotiate returns the encoder that best matches the Accept header, or false if the client accepts none of them.
+func (e *Encoders) Negotiate(accept string) (ResponseEncoder, bool) { //nolint:ireturn
+	if strings.TrimSpace(accept) == "" {
+		return e.Default(), true
+	}
+
+	return e.negotiate(accept, func(ResponseEncoder) bool { return true })
+}
+
+// NegotiateFor is like Negotiate, but skips the encoders that cannot encode v, moving on to the next acceptable ones.
+func (e *Encoders) NegotiateFor(accept string, v any) (ResponseEncoder, bool) { //nolint:ireturn
+	if strings.TrimSpace(accept) == "" {
+		accept = "*/*"
+	}
+
+	if enc, ok := e.negotiate(accept, func(enc ResponseEncoder) bool { return canEncode(enc, v) }); ok {
+		return enc, true
+	}
+
+	// An empty registry encodes to JSON, as Default does.
+	if enc := e.Default(); e.isEmpty() && canEncode(enc, v) {
+		return enc, true
+	}
+
+	return nil, false
+}
+
+// negotiate returns the first encoder that the client accepts and that satisfies ok, following the client's preferences.
+func (e *Encoders) negotiate(accept string, ok func(ResponseEncoder) bool) (ResponseEncoder, bool) { //nolint:ireturn
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	for _, mediaRange := range parseAccept(accept) {
+		for _, enc := range e.encoders {
+			if mediaRange.matches(enc.ContentType()) && ok(enc) {
+				return enc, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+func (e *Encoders) isEmpty() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	return len(e.encoders) == 0
+}
+
+// mediaRange is an entry of the Accept header.
+type mediaRange struct {
+	mediaType string
+	quality   float64
+}
+
+// parseAccept returns the media ranges of the Accept header, from the most to the least preferred.
+// Ranges with a quality of 0 are not acceptable, and are left out.
+func parseAccept(accept string) []mediaRange {
+	ranges := make([]mediaRange, 0, strings.Count(accept, ",")+1)
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+
+		if quality > 0 {
+			ranges = append(ranges, mediaRange{mediaType: mediaType, quality: quality})
+		}
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].quality != ranges[j].quality {
+			return ranges[i].quality > ranges[j].quality
+		}
+
+		return ranges[i].specificity() > ranges[j].specificity()
+	})
+
+	return ranges
+}
+
+func (m mediaRange) matches(contentType string) bool {
+	switch {
+	case m.mediaType == "*/*":
+		return true
+	case strings.HasSuffix(m.mediaType, "/*"):
+		return strings.HasPrefix(contentType, strings.TrimSuffix(m.mediaType, "*"))
+	default:
+		return m.mediaType == contentType
+	}
+}
+
+// specificity ranks exact media types above "type/*", and "type/*" above "*/*".
+func (m mediaRange) specificity() int {
+	switch {
+	case m.mediaType == "*/*":
+		return 0
+	case strings.HasSuffix(m.mediaType, "/*"):
+		return 1
+	default:
+		return 2 //nolint:mnd
+	}
+}
+
+// canEncode reports whether enc can encode v.
+func canEncode(enc ResponseEncoder, v any) bool {
+	if checker, ok := enc.(canEncoder); ok {
+		return checker.CanEncode(v)
+	}
+
+	return true
+}
+
+// JSONEncoder encodes values to JSON. It is the default ResponseEncoder.
+type JSONEncoder struct{}
+
+func (JSONEncoder) ContentType() string {
+	return MediaTypeJSON
+}
+
+func (JSONEncoder) Encode(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v) //nolint:wrapcheck
+}
+
+// NDJSONEncoder encodes slices to newline-delimited JSON, one element per line. Other values are written as a single line.
+type NDJSONEncoder struct{}
+
+func (NDJSONEncoder) ContentType() string {
+	return MediaTypeNDJSON
+}
+
+func (NDJSONEncoder) Encode(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	value := reflect.ValueOf(v)
+
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return enc.Encode(v) //nolint:wrapcheck
+	}
+
+	for i := range value.Len() {
+		if err := enc.Encode(value.Index(i).Interface()); err != nil {
+			return err //nolint:wrapcheck
+		}
+	}
+
+	return nil
+}
+
+// XMLEncoder encodes values to XML.
+type XMLEncoder struct{}
+
+func (XMLEncoder) ContentType() string {
+	return MediaTypeXML
+}
+
+// CanEncode reports whether v encodes to a well-formed document. Maps cannot be encoded, and nil values or slices
+// have no root element, unless they implement xml.Marshaler.
+func (XMLEncoder) CanEncode(v any) bool {
+	value := reflect.ValueOf(v)
+	if !value.IsValid() {
+		return false
+	}
+
+	if value.Type().Implements(reflect.TypeFor[xml.Marshaler]()) {
+		return true
+	}
+
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return false
+		}
+
+		value = value.Elem()
+	}
+
+	switch value.Kind() { //nolint:exhaustive
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Chan, reflect.Func:
+		return false
+	default:
+		return true
+	}
+}
+
+func (XMLEncoder) Encode(w io.Writer, v any) error {
+	return xml.NewEncoder(w).Encode(v) //nolint:wrapcheck
+}
+
+// CSVEncoder encodes slices of structs to CSV, with a header row.
+// Columns are named after the `csv` struct tags, or the `json` ones, or the field names.
+type CSVEncoder struct{}
+
+func (CSVEncoder) ContentType() string {
+	return MediaTypeCSV
+}
+
+// CanEncode reports whether v is a slice of structs (or of pointers to structs).
+func (CSVEncoder) CanEncode(v any) bool {
+	_, ok := csvRowType(reflect.TypeOf(v))
+
+	return ok
+}
+
+func (CSVEncoder) Encode(w io.Writer, v any) error {
+	rowType, ok := csvRowType(reflect.TypeOf(v))
+	if !ok {
+		return errors.New("cannot encode " + reflect.TypeOf(v).String() + " to CSV") //nolint:err113
+	}
+
+	var (
+		header  []string
+		columns []int
+	)
+
+	for i := range rowType.NumField() {
+		field := rowType.Field(i)
+
+		name := field.Tag.Get("csv")
+		if name == "" && field.Tag.Get("json") != "-" {
+			name = jsonName(&field)
+		}
+
+		if !field.IsExported() || name == "" || name == "-" {
+			continue
+		}
+
+		header = append(header, name)
+		columns = append(columns, i)
+	}
+
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(header); err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	value := reflect.ValueOf(v)
+	record := make([]string, len(columns))
+
+	for i := range value.Len() {
+		row := value.Index(i)
+		if row.Kind() == reflect.Ptr {
+			if row.IsNil() {
+				continue
+			}
+
+			row = row.Elem()
+		}
+
+		for j, col := range columns {
+			record[j] = csvValue(row.Field(col))
+		}
+
+		if err := cw.Write(record); err != nil {
+			return err //nolint:wrapcheck
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error() //nolint:wrapcheck
+}
+
+// csvRowType returns the struct type of the rows of a slice.
+func csvRowType(t reflect.Type) (reflect.Type, bool) {
+	if t == nil || (t.Kind() != reflect.Slice && t.Kind() != reflect.Array) {
+		return nil, false
+	}
+
+	row := t.Elem()
+	if row.Kind() == reflect.Ptr {
+		row = row.Elem()
+	}
+
+	return row, row.Kind() == reflect.Struct
+}
+
+// csvValue formats a field as a CSV value.
+func csvValue(value reflect.Value) string {
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return ""
+		}
+
+		value = value.Elem()
+	}
+
+	switch v := value.Interface().(type) {
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case encoding.TextMarshaler:
+		text, err := v.MarshalText()
+		if err != nil {
+			return ""
+		}
+
+		return string(text)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// MsgPackEncoder encodes values to MessagePack. Values are converted as they would be to JSON, so `json` struct tags apply.
+type MsgPackEncoder struct{}
+
+func (MsgPackEncoder) ContentType() string {
+	return MediaTypeMsgPack
+}
+
+func (MsgPackEncoder) Encode(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	var generic any
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	if err := dec.Decode(&generic); err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	var buf bytes.Buffer
+
+	writeMsgPack(&buf, generic)
+
+	_, err = w.Write(buf.Bytes())
+
+	return err //nolint:wrapcheck
+}
+
+// writeMsgPack writes a value decoded from JSON in MessagePack format.
+//
+//nolint:cyclop,gosec,mnd // Follows the MessagePack specification.
+func writeMsgPack(buf *bytes.Buffer, v any) {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteByte(0xc0)
+	case bool:
+		if v {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			switch {
+			case n >= 0 && n <= 0x7f:
+				buf.WriteByte(byte(n))
+			case n < 0 && n >= -32:
+				buf.WriteByte(byte(int8(n)))
+			default:
+				buf.WriteByte(0xd3)
+				buf.Write(binary.BigEndian.AppendUint64(nil, uint64(n)))
+			}
+
+			return
+		}
+
+		f, _ := v.Float64()
+
+		buf.WriteByte(0xcb)
+		buf.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(f)))
+	case string:
+		writeMsgPackHeader(buf, len(v), 0xa0, 32, 0xd9, 0xda, 0xdb)
+		buf.WriteString(v)
+	case []any:
+		writeMsgPackHeader(buf, len(v), 0x90, 16, 0, 0xdc, 0xdd)
+
+		for _, item := range v {
+			writeMsgPack(buf, item)
+		}
+	case map[string]any:
+		writeMsgPackHeader(buf, len(v), 0x80, 16, 0, 0xde, 0xdf)
+
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			writeMsgPack(buf, k)
+			writeMsgPack(buf, v[k])
+		}
+	}
+}
+
+// writeMsgPackHeader writes the type and length of a string, array or map.
+// Formats that don't exist for the type (eg. 8-bit arrays) are passed as 0.
+//
+//nolint:gosec,mnd // Follows the MessagePack specification.
+func writeMsgPackHeader(buf *bytes.Buffer, length int, fix byte, fixMax int, fmt8, fmt16, fmt32 byte) {
+	switch {
+	case length < fixMax:
+		buf.WriteByte(fix | byte(length))
+	case fmt8 != 0 && length <= math.MaxUint8:
+		buf.WriteByte(fmt8)
+		buf.WriteByte(byte(length))
+	case length <= math.MaxUint16:
+		buf.WriteByte(fmt16)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(length)))
+	default:
+		buf.WriteByte(fmt32)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(length)))
+	}
+}
@@ -0,0 +1,171 @@
+package handler_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/luca-arch/go-goodies/handler"
+	"github.com/luca-arch/go-goodies/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+type Row struct {
+	ID    int     `json:"id"`
+	Name  string  `json:"name"`
+	Note  *string `csv:"notes" json:"note,omitempty"`
+	Price float64 `json:"-"`
+}
+
+// Unencodable fails to encode to JSON.
+type Unencodable struct{}
+
+func (Unencodable) MarshalJSON() ([]byte, error) {
+	return nil, errors.New("unsupported value")
+}
+
+func TestEncoders(t *testing.T) {
+	t.Parallel()
+
+	note := "fragile"
+	rows := []Row{{ID: 1, Name: "pen", Note: &note}, {ID: 2, Name: "ink, blue"}}
+
+	list := handler.WithOutput(logger.NewNop(), func(_ context.Context) ([]Row, error) {
+		return rows, nil
+	})
+
+	item := handler.WithOutput(logger.NewNop(), func(_ context.Context) (*Row, error) {
+		return &rows[0], nil
+	})
+
+	broken := handler.WithOutput(logger.NewNop(), func(_ context.Context) (*handler.Response[Unencodable], error) {
+		return handler.Created("/unencodable/1", Unencodable{}), nil
+	})
+
+	single := handler.WithOutput(logger.NewNop(), func(_ context.Context) (map[string]any, error) {
+		return map[string]any{"a": 1, "b": []any{true, nil, "x"}}, nil
+	})
+
+	failing := handler.WithOutput(logger.NewNop(), func(_ context.Context) ([]Row, error) {
+		return nil, handler.NotFound(errors.New("no rows"))
+	}, handler.ProblemDetails())
+
+	tests := map[string]struct {
+		handler     http.Handler
+		accept      string
+		status      int
+		contentType string
+		body        string
+	}{
+		"json by default": {
+			handler:     list,
+			status:      http.StatusOK,
+			contentType: "application/json",
+			body:        `[{"id":1,"name":"pen","note":"fragile"},{"id":2,"name":"ink, blue"}]` + "\n",
+		},
+		"json from wildcard": {
+			handler:     list,
+			accept:      "text/html, */*;q=0.8",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			body:        `[{"id":1,"name":"pen","note":"fragile"},{"id":2,"name":"ink, blue"}]` + "\n",
+		},
+		"ndjson": {
+			handler:     list,
+			accept:      "application/x-ndjson",
+			status:      http.StatusOK,
+			contentType: "application/x-ndjson",
+			body:        `{"id":1,"name":"pen","note":"fragile"}` + "\n" + `{"id":2,"name":"ink, blue"}` + "\n",
+		},
+		"csv preferred by quality": {
+			handler:     list,
+			accept:      "application/json;q=0.5, text/csv",
+			status:      http.StatusOK,
+			contentType: "text/csv",
+			body:        "id,name,notes\n1,pen,fragile\n2,\"ink, blue\",\n",
+		},
+		"xml": {
+			handler:     item,
+			accept:      "application/xml",
+			status:      http.StatusOK,
+			contentType: "application/xml",
+			body:        "<Row><ID>1</ID><Name>pen</Name><Note>fragile</Note><Price>0</Price></Row>",
+		},
+		"xml not possible for lists": {
+			handler:     list,
+			accept:      "application/xml",
+			status:      http.StatusNotAcceptable,
+			contentType: "application/json",
+			body:        `{"error":"not acceptable\ncannot encode the response to application/xml"}` + "\n",
+		},
+		"browser": {
+			handler:     single,
+			accept:      "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			body:        `{"a":1,"b":[true,null,"x"]}` + "\n",
+		},
+		"next acceptable format": {
+			handler:     single,
+			accept:      "text/csv, application/json;q=0.9",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			body:        `{"a":1,"b":[true,null,"x"]}` + "\n",
+		},
+		"msgpack": {
+			handler:     single,
+			accept:      "application/msgpack",
+			status:      http.StatusOK,
+			contentType: "application/msgpack",
+			body:        "\x82\xa1a\x01\xa1b\x93\xc3\xc0\xa1x",
+		},
+		"csv not possible": {
+			handler:     single,
+			accept:      "text/csv",
+			status:      http.StatusNotAcceptable,
+			contentType: "application/json",
+			body:        `{"error":"not acceptable\ncannot encode the response to text/csv"}` + "\n",
+		},
+		"nothing acceptable": {
+			handler:     list,
+			accept:      "text/html",
+			status:      http.StatusNotAcceptable,
+			contentType: "application/json",
+			body:        `{"error":"not acceptable"}` + "\n",
+		},
+		"encoding failure": {
+			handler:     broken,
+			accept:      "",
+			status:      http.StatusInternalServerError,
+			contentType: "application/json",
+			body:        `{"error":"cannot encode the response"}` + "\n",
+		},
+		"problem as xml": {
+			handler:     failing,
+			accept:      "application/xml",
+			status:      http.StatusNotFound,
+			contentType: "application/problem+xml",
+			body:        `<problem xmlns="urn:ietf:rfc:7807"><type>about:blank</type><title>Not Found</title><status>404</status><detail>no rows</detail><instance>/</instance></problem>`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.accept != "" {
+				r.Header.Set("Accept", test.accept)
+			}
+
+			w := httptest.NewRecorder()
+			test.handler.ServeHTTP(w, r)
+
+			assert.Equal(t, test.status, w.Code)
+			assert.Equal(t, test.contentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, test.body, w.Body.String())
+		})
+	}
+}
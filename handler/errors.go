@@ -0,0 +1,295 @@
+package handler
+
+import (
+	"cmp"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Codes of the field errors that are not named after a validation rule.
+const (
+	CodeInvalid  = "invalid"
+	CodeRequired = "required"
+	CodeUnknown  = "unknown"
+)
+
+// SourceBody is the source of the field errors found in the request's body.
+const SourceBody = "body"
+
+// FieldError describes why a single field of the request could not be accepted.
+// Code is either CodeInvalid, CodeRequired or the name of the validation rule that failed (eg. "min").
+type FieldError struct {
+	Field   string `json:"field"   xml:"field,attr"`
+	Source  string `json:"source"  xml:"source,attr"`
+	Code    string `json:"code"    xml:"code,attr"`
+	Message string `json:"message" xml:",chardata"`
+}
+
+func (e *FieldError) Error() string {
+	return e.Message
+}
+
+// FieldErrors gathers all the field errors found in a request.
+type FieldErrors []*FieldError
+
+func (e FieldErrors) Error() string {
+	messages := make([]string, len(e))
+
+	for i, fieldErr := range e {
+		messages[i] = fieldErr.Message
+	}
+
+	return strings.Join(messages, "\n")
+}
+
+// toFieldErrors converts err to field errors, unless it is one already.
+func toFieldErrors(err error, field, source string) FieldErrors {
+	var fieldErrs FieldErrors
+
+	if errors.As(err, &fieldErrs) {
+		return fieldErrs
+	}
+
+	return FieldErrors{{Field: field, Source: source, Code: CodeInvalid, Message: err.Error()}}
+}
+
+// Problem is an error carrying RFC 9457 problem details. It is served as is when the handler uses the ProblemDetails option.
+// Extensions are serialised as additional members of the problem object.
+type Problem struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]any
+	Err        error
+}
+
+// NewProblem attaches a problem type URI and extension members to err.
+func NewProblem(err error, typeURI string, extensions map[string]any) *Problem {
+	return &Problem{
+		Type:       typeURI,
+		Title:      "",
+		Status:     0,
+		Detail:     "",
+		Instance:   "",
+		Extensions: extensions,
+		Err:        err,
+	}
+}
+
+func (p *Problem) Error() string {
+	switch {
+	case p.Detail != "":
+		return p.Detail
+	case p.Err != nil:
+		return p.Err.Error()
+	default:
+		return p.Title
+	}
+}
+
+func (p *Problem) StatusCode() int {
+	return p.Status
+}
+
+func (p *Problem) Unwrap() error {
+	return p.Err
+}
+
+// MarshalJSON encodes the problem as an application/problem+json object.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	members := make(map[string]any, len(p.Extensions)+5) //nolint:mnd // Standard members.
+
+	for k, v := range p.Extensions {
+		members[k] = v
+	}
+
+	members["type"] = p.Type
+	if p.Type == "" {
+		members["type"] = "about:blank"
+	}
+
+	for k, v := range map[string]string{"title": p.Title, "detail": p.Detail, "instance": p.Instance} {
+		if v != "" {
+			members[k] = v
+		} else {
+			delete(members, k)
+		}
+	}
+
+	if p.Status != 0 {
+		members["status"] = p.Status
+	}
+
+	return json.Marshal(members) //nolint:wrapcheck
+}
+
+// MarshalXML encodes the problem as an application/problem+xml document.
+func (p *Problem) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	start := xml.StartElement{Name: xml.Name{Space: "urn:ietf:rfc:7807", Local: "problem"}, Attr: nil}
+
+	if err := e.EncodeToken(start); err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	members := []struct {
+		name  string
+		value any
+	}{
+		{"type", cmp.Or(p.Type, "about:blank")},
+		{"title", p.Title},
+		{"status", p.Status},
+		{"detail", p.Detail},
+		{"instance", p.Instance},
+	}
+
+	for _, k := range slices.Sorted(maps.Keys(p.Extensions)) {
+		members = append(members, struct {
+			name  string
+			value any
+		}{k, p.Extensions[k]})
+	}
+
+	for _, member := range members {
+		if member.value == nil || reflect.ValueOf(member.value).IsZero() {
+			continue
+		}
+
+		if err := e.EncodeElement(member.value, xml.StartElement{Name: xml.Name{Space: "", Local: member.name}, Attr: nil}); err != nil {
+			return err //nolint:wrapcheck
+		}
+	}
+
+	return e.EncodeToken(start.End()) //nolint:wrapcheck
+}
+
+// StatusCoder is implemented by errors that choose the HTTP status they are served with.
+type StatusCoder interface {
+	StatusCode() int
+}
+
+// Headerer is implemented by errors that add headers to the response they are served with (eg. Retry-After).
+type Headerer interface {
+	Header() http.Header
+}
+
+// StatusError is an error served with a specific HTTP status and headers.
+type StatusError struct {
+	Status  int
+	Headers http.Header
+	Err     error
+}
+
+// NewStatusError returns an error that is served with the given HTTP status.
+func NewStatusError(status int, err error) *StatusError {
+	return &StatusError{Status: status, Headers: http.Header{}, Err: err}
+}
+
+// BadRequest returns an error that is served with 400 Bad Request.
+func BadRequest(err error) *StatusError {
+	return NewStatusError(http.StatusBadRequest, err)
+}
+
+// Unauthorized returns an error that is served with 401 Unauthorized.
+func Unauthorized(err error) *StatusError {
+	return NewStatusError(http.StatusUnauthorized, err)
+}
+
+// Forbidden returns an error that is served with 403 Forbidden.
+func Forbidden(err error) *StatusError {
+	return NewStatusError(http.StatusForbidden, err)
+}
+
+// NotFound returns an error that is served with 404 Not Found.
+func NotFound(err error) *StatusError {
+	return NewStatusError(http.StatusNotFound, err)
+}
+
+// Conflict returns an error that is served with 409 Conflict.
+func Conflict(err error) *StatusError {
+	return NewStatusError(http.StatusConflict, err)
+}
+
+// Unprocessable returns an error that is served with 422 Unprocessable Entity.
+func Unprocessable(err error) *StatusError {
+	return NewStatusError(http.StatusUnprocessableEntity, err)
+}
+
+// TooManyRequests returns an error that is served with 429 Too Many Requests, and tells the client when to retry.
+func TooManyRequests(err error, retryAfter time.Duration) *StatusError {
+	return NewStatusError(http.StatusTooManyRequests, err).SetHeader("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+}
+
+// ServiceUnavailable returns an error that is served with 503 Service Unavailable, and tells the client when to retry.
+func ServiceUnavailable(err error, retryAfter time.Duration) *StatusError {
+	return NewStatusError(http.StatusServiceUnavailable, err).SetHeader("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+}
+
+// SetHeader sets a header of the response the error is served with.
+func (e *StatusError) SetHeader(key, value string) *StatusError {
+	if e.Headers == nil {
+		e.Headers = http.Header{}
+	}
+
+	e.Headers.Set(key, value)
+
+	return e
+}
+
+func (e *StatusError) Error() string {
+	if e.Err == nil {
+		return http.StatusText(e.Status)
+	}
+
+	return e.Err.Error()
+}
+
+func (e *StatusError) Header() http.Header {
+	return e.Headers
+}
+
+func (e *StatusError) StatusCode() int {
+	return e.Status
+}
+
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
+
+// publicError marks an error whose message can be served to clients in safe mode.
+type publicError struct {
+	err error
+}
+
+// Public marks err as safe to be served to clients, even when the handler uses the SafeErrors option.
+func Public(err error) error {
+	return &publicError{err: err}
+}
+
+func (e *publicError) Error() string {
+	return e.err.Error()
+}
+
+func (e *publicError) Unwrap() error {
+	return e.err
+}
+
+// maskedError replaces an internal error that must not reach clients.
+// The correlation ID lets operators find the original error in the logs.
+type maskedError struct {
+	status        int
+	correlationID string
+}
+
+func (e *maskedError) Error() string {
+	return http.StatusText(e.status)
+}
@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"runtime/debug"
+
+	"github.com/luca-arch/go-goodies/handler/middleware"
+)
+
+// Func is an HTTP handler that takes the decoded request and returns a generic output.
+type Func[Args any, In any, Out any] func(context.Context, *Request[Args, In]) (Out, error)
+
+// Middleware wraps an HTTP handler with additional behaviour.
+type Middleware func(http.Handler) http.Handler
+
+// Request holds an HTTP request along with its decoded query arguments and body.
+type Request[Args any, In any] struct {
+	*http.Request
+	Args Args
+	In   In
+}
+
+// New takes a Func and uses it to create an HTTP handler, whose behaviour is customised via options.
+// Args are read via InputFromRequest, and the request's body is decoded into In unless In is struct{}.
+// The response's format is negotiated via the Accept header, and 406 is served if none is acceptable (unless Out is a *Download, or a Response of one).
+// The context passed to f carries a logger with the request's attributes, which can be retrieved via logger.FromContext.
+// It panics if the parameters of the rules declared in the tags of Args or In are invalid (eg. `validate:"min=one"`).
+// All the With* helpers are built on top of it.
+func New[Args any, In any, Out any](f Func[Args, In, Out], opts ...Option) http.Handler {
+	cfg := newConfig(opts)
+	hasBody := reflect.TypeFor[In]() != reflect.TypeFor[struct{}]()
+	isDownload := outputBodyType(reflect.TypeFor[Out]()) == reflect.TypeFor[*Download]()
+
+	mustCheckRules(reflect.TypeFor[Args](), reflect.TypeFor[In]())
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+
+		r, cfg := withRequestLogger(r, cfg)
+
+		cfg.logger.Debug("HTTP request", "http.url", r.URL)
+
+		w.Header().Add("Vary", "Accept")
+
+		enc, ok := cfg.encoders.Negotiate(r.Header.Get("Accept"))
+		if !ok && !isDownload {
+			writeInputError(w, r, cfg, cfg.encoders.Default(), errNotAcceptable(nil))
+
+			return
+		}
+
+		// Downloads are served whatever the client accepts, and their errors in the default format.
+		if !ok {
+			enc = cfg.encoders.Default()
+		}
+
+		// The writer records whether the response was started, in case of panics.
+		rw := middleware.NewResponseWriter(w)
+		w = rw
+
+		defer recoverPanic(rw, r, cfg, enc)
+
+		req := &Request[Args, In]{Request: r} //nolint:exhaustruct // Filled below.
+
+		req.Args, err = readArgs[Args](r, cfg)
+		if err != nil {
+			writeInputError(w, r, cfg, enc, err)
+
+			return
+		}
+
+		if hasBody {
+			// Parsed multipart forms may have spilled files to disk, which are removed whatever the outcome.
+			defer removeMultipartForm(r)
+
+			req.In, err = readBody[In](w, r, cfg)
+			if err != nil {
+				writeInputError(w, r, cfg, enc, err)
+
+				return
+			}
+		}
+
+		// Call out to target function.
+		out, err := f(r.Context(), req)
+
+		// Serve response.
+		writeResponse(w, r, cfg, enc, out, err)
+	})
+
+	return &typedHandler{
+		Handler: wrap(h, cfg.middlewares),
+		types: handlerTypes{
+			args:   reflect.TypeFor[Args](),
+			in:     reflect.TypeFor[In](),
+			out:    reflect.TypeFor[Out](),
+			stream: "",
+			cfg:    cfg,
+		},
+	}
+}
+
+// wrap wraps h with the given middlewares, the first one being the outermost.
+func wrap(h http.Handler, mws []Middleware) http.Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+
+	return h
+}
+
+// removeMultipartForm removes the temporary files of the request's multipart form, if it has been parsed.
+// The request passed to the handler is a copy, which net/http doesn't clean up by itself.
+func removeMultipartForm(r *http.Request) {
+	if r.MultipartForm != nil {
+		_ = r.MultipartForm.RemoveAll()
+	}
+}
+
+// recoverPanic recovers from a panic of the handler, logs it along with the stack trace, and serves ErrPanic.
+// http.ErrAbortHandler is left to net/http, as it is meant to abort the response. Responses that were already started
+// cannot be replaced, so they are aborted likewise.
+func recoverPanic(w *middleware.ResponseWriter, r *http.Request, cfg *config, enc ResponseEncoder) {
+	v := recover()
+	if v == nil {
+		return
+	}
+
+	if v == http.ErrAbortHandler {
+		panic(v)
+	}
+
+	cfg.logger.Error("HTTP handler panicked",
+		"panic", v,
+		"stack", string(debug.Stack()),
+		"http.url", r.URL,
+	)
+
+	if w.Written() {
+		panic(http.ErrAbortHandler)
+	}
+
+	writeResponse[any](w, r, cfg, enc, nil, ErrPanic)
+}
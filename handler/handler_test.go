@@ -0,0 +1,231 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/luca-arch/go-goodies/handler"
+	"github.com/luca-arch/go-goodies/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+type UpdateArgs struct {
+	PK int `in:"pk,path,required"`
+}
+
+type UpdateInput struct {
+	Name string `json:"name"`
+}
+
+func noAdmin(v any) error {
+	if in, ok := v.(UpdateInput); ok && in.Name == "admin" {
+		return errors.New("reserved name")
+	}
+
+	return nil
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		body   string
+		status int
+		want   string
+	}{
+		"ok": {
+			body:   `{"name":"pen"}`,
+			status: http.StatusOK,
+			want:   `{"method":"PUT","name":"pen","pk":7}` + "\n",
+		},
+		"validator error": {
+			body:   `{"name":"admin"}`,
+			status: http.StatusBadRequest,
+			want:   `{"error":"invalid input\nreserved name"}` + "\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var order []string
+
+			trace := func(name string) handler.Middleware {
+				return func(next http.Handler) http.Handler {
+					return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						order = append(order, name)
+						next.ServeHTTP(w, r)
+					})
+				}
+			}
+
+			h := handler.New(func(_ context.Context, r *handler.Request[UpdateArgs, UpdateInput]) (map[string]any, error) {
+				return map[string]any{"pk": r.Args.PK, "name": r.In.Name, "method": r.Method}, nil
+			}, handler.Use(trace("outer"), trace("inner")), handler.Validators(noAdmin))
+
+			mux := http.NewServeMux()
+			mux.Handle("PUT /items/{pk}", h)
+
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/items/7", strings.NewReader(test.body)))
+
+			assert.Equal(t, test.status, w.Code)
+			assert.Equal(t, test.want, w.Body.String())
+			assert.Equal(t, []string{"outer", "inner"}, order)
+		})
+	}
+}
+
+func TestNewPanic(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		opts []handler.Option
+		want string
+	}{
+		"default": {
+			opts: nil,
+			want: `{"error":"internal error"}` + "\n",
+		},
+		"problem details": {
+			opts: []handler.Option{handler.ProblemDetails()},
+			want: `{"detail":"internal error","instance":"/boom","status":500,"title":"Internal Server Error","type":"about:blank"}` + "\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var logs bytes.Buffer
+
+			opts := append([]handler.Option{handler.Logger(slog.New(slog.NewJSONHandler(&logs, nil)))}, test.opts...)
+
+			h := handler.New(func(_ context.Context, _ *handler.Request[struct{}, struct{}]) (any, error) {
+				panic("boom")
+			}, opts...)
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/boom", nil))
+
+			assert.Equal(t, http.StatusInternalServerError, w.Code)
+			assert.Equal(t, test.want, w.Body.String())
+			assert.Contains(t, logs.String(), `"msg":"HTTP handler panicked","http.method":"GET","panic":"boom"`)
+			assert.Contains(t, logs.String(), "TestNewPanic")
+		})
+	}
+}
+
+// brokenContent is the content of a download that panics once the response has been started.
+type brokenContent struct{}
+
+func (brokenContent) Read([]byte) (int, error) {
+	panic("boom")
+}
+
+func (brokenContent) Seek(offset int64, whence int) (int64, error) {
+	if whence == io.SeekEnd {
+		return 10, nil //nolint:mnd
+	}
+
+	return offset, nil
+}
+
+func TestNewPanicAfterWriting(t *testing.T) {
+	t.Parallel()
+
+	var logs bytes.Buffer
+
+	h := handler.WithOutput(slog.New(slog.NewJSONHandler(&logs, nil)), func(_ context.Context) (*handler.Download, error) {
+		return handler.NewDownload(brokenContent{}, "report.bin", "application/octet-stream"), nil
+	})
+
+	w := httptest.NewRecorder()
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/report", nil))
+	})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Contains(t, logs.String(), `"msg":"HTTP handler panicked"`)
+}
+
+func TestNewRequestLogger(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		header string
+		scoped bool
+		want   string
+	}{
+		"request attributes": {
+			header: "",
+			scoped: false,
+			want:   `{"level":"INFO","msg":"hello","http.method":"GET","http.route":"GET /items/{pk}"}`,
+		},
+		"request id": {
+			header: "abc123",
+			scoped: false,
+			want:   `{"level":"INFO","msg":"hello","http.method":"GET","http.route":"GET /items/{pk}","request_id":"abc123"}`,
+		},
+		"invalid request ID": {
+			header: `abc123","user":"root`,
+			scoped: false,
+			want:   `{"level":"INFO","msg":"hello","http.method":"GET","http.route":"GET /items/{pk}"}`,
+		},
+		"logger of the context": {
+			header: "",
+			scoped: true,
+			want:   `{"level":"INFO","msg":"hello","user":"alice","http.method":"GET","http.route":"GET /items/{pk}"}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var logs bytes.Buffer
+
+			l := slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{ //nolint:exhaustruct
+				ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+					if a.Key == slog.TimeKey {
+						return slog.Attr{} //nolint:exhaustruct
+					}
+
+					return a
+				},
+			}))
+
+			h := handler.New(func(ctx context.Context, _ *handler.Request[struct{}, struct{}]) (any, error) {
+				logger.FromContext(ctx).Info("hello")
+
+				return nil, nil
+			}, handler.Logger(l))
+
+			mux := http.NewServeMux()
+			mux.Handle("GET /items/{pk}", h)
+
+			r := httptest.NewRequest(http.MethodGet, "/items/7", nil)
+			if test.header != "" {
+				r.Header.Set("X-Request-Id", test.header)
+			}
+
+			if test.scoped {
+				r = r.WithContext(logger.WithContext(r.Context(), l.With("user", "alice")))
+			}
+
+			mux.ServeHTTP(httptest.NewRecorder(), r)
+
+			assert.JSONEq(t, test.want, strings.TrimSpace(logs.String()))
+		})
+	}
+}
@@ -0,0 +1,227 @@
+// package handlertest provides helpers to test the HTTP handlers created by the handler package.
+// Requests are built from typed Args and In, and responses decoded into Out or an ErrResponse, as handler.Call does.
+// Response bodies can be compared with golden files, which are rewritten when the tests run with -update-golden.
+package handlertest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/luca-arch/go-goodies/handler"
+)
+
+// update tells Golden to rewrite the golden files instead of comparing against them.
+var update = flag.Bool("update-golden", false, "rewrite the golden files of handlertest") //nolint:gochecknoglobals
+
+// TB is the subset of testing.TB used by this package.
+type TB interface {
+	Errorf(format string, args ...any)
+	Fatalf(format string, args ...any)
+	Helper()
+}
+
+// Option customises the request built by Do.
+type Option func(*http.Request)
+
+// Header sets a header of the request (eg. Accept), on top of the ones read by Args.
+func Header(key, value string) Option {
+	return func(r *http.Request) {
+		r.Header.Set(key, value)
+	}
+}
+
+// Result is the outcome of a request served by a handler.
+// Either Out or Error is set, depending on whether the response has a 2xx status.
+type Result[Out any] struct {
+	Status int
+	Header http.Header
+	Body   []byte
+	Out    Out
+	Error  *handler.ErrResponse
+}
+
+// Do builds a request from args and in, serves it with h, and decodes the response.
+// Pattern is the path of the route, with its wildcards (eg. "/orders/{pk}"), possibly preceded by a scheme and a host.
+// The request is routed via an http.ServeMux, so that h reads the path values. See handler.NewRequest for how Args and In are encoded.
+// Out is decoded from JSON, unless it is struct{}. It comes first, so that Args and In can be inferred (eg. Do[Order](t, ...)).
+func Do[Out any, Args any, In any](t TB, h http.Handler, method, pattern string, args Args, in In, opts ...Option) *Result[Out] {
+	t.Helper()
+
+	r, err := handler.NewRequest(context.Background(), method, pattern, args, in)
+	if err != nil {
+		t.Fatalf("handlertest: cannot build the request: %v", err)
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	// The route is the path of the pattern, which may also have a scheme and a host.
+	route, _, _ := strings.Cut(pattern, "?")
+	if _, rest, ok := strings.Cut(route, "://"); ok {
+		route = "/"
+		if slash := strings.Index(rest, "/"); slash >= 0 {
+			route = rest[slash:]
+		}
+	}
+
+	if method != "" {
+		route = method + " " + route
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(route, h)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	result := &Result[Out]{
+		Status: res.StatusCode,
+		Header: res.Header,
+		Body:   w.Body.Bytes(),
+		Out:    *new(Out),
+		Error:  nil,
+	}
+
+	var clientErr *handler.ClientError
+
+	out, err := handler.DecodeResponse[Out](res)
+
+	switch {
+	case errors.As(err, &clientErr):
+		result.Error = &clientErr.Body
+	case err != nil:
+		t.Fatalf("handlertest: cannot decode the response: %v\n%s", err, result.Body)
+	default:
+		result.Out = out
+	}
+
+	return result
+}
+
+// AssertStatus checks the status of the response.
+func (r *Result[Out]) AssertStatus(t TB, status int) *Result[Out] {
+	t.Helper()
+
+	if r.Status != status {
+		t.Errorf("handlertest: status is %d, want %d\n%s", r.Status, status, r.Body)
+	}
+
+	return r
+}
+
+// AssertHeader checks a header of the response.
+func (r *Result[Out]) AssertHeader(t TB, key, value string) *Result[Out] {
+	t.Helper()
+
+	if got := r.Header.Get(key); got != value {
+		t.Errorf("handlertest: header %s is %q, want %q", key, got, value)
+	}
+
+	return r
+}
+
+// AssertOut checks that the response was successful, and that its body decodes to want.
+func (r *Result[Out]) AssertOut(t TB, want Out) *Result[Out] {
+	t.Helper()
+
+	switch {
+	case r.Error != nil:
+		t.Errorf("handlertest: response is an error (%d): %s", r.Status, r.Error.Error)
+	case !reflect.DeepEqual(r.Out, want):
+		t.Errorf("handlertest: output is %+v, want %+v", r.Out, want)
+	}
+
+	return r
+}
+
+// AssertError checks that the response is an error with the given status and message.
+func (r *Result[Out]) AssertError(t TB, status int, message string) *Result[Out] {
+	t.Helper()
+
+	switch {
+	case r.Error == nil:
+		t.Errorf("handlertest: response is not an error (%d)\n%s", r.Status, r.Body)
+	case r.Status != status || r.Error.Error != message:
+		t.Errorf("handlertest: error is %d %q, want %d %q", r.Status, r.Error.Error, status, message)
+	}
+
+	return r
+}
+
+// AssertFieldError checks that the response reports an error for the field, with the given code (eg. handler.CodeRequired).
+func (r *Result[Out]) AssertFieldError(t TB, field, code string) *Result[Out] {
+	t.Helper()
+
+	if r.Error != nil {
+		for _, fieldErr := range r.Error.Fields {
+			if fieldErr.Field == field && fieldErr.Code == code {
+				return r
+			}
+		}
+	}
+
+	t.Errorf("handlertest: no %s error for field %s\n%s", code, field, r.Body)
+
+	return r
+}
+
+// AssertGolden compares the body of the response with a golden file, see Golden.
+func (r *Result[Out]) AssertGolden(t TB, name string) *Result[Out] {
+	t.Helper()
+
+	Golden(t, name, r.Body)
+
+	return r
+}
+
+// Golden compares got with the file testdata/<name>.golden, or rewrites the file when the tests run with -update-golden.
+// JSON documents are indented first, so that golden files are readable and diffs meaningful.
+func Golden(t TB, name string, got []byte) {
+	t.Helper()
+
+	got = bytes.TrimSpace(got)
+
+	if json.Valid(got) {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, got, "", "  "); err == nil {
+			got = indented.Bytes()
+		}
+	}
+
+	got = append(got, '\n')
+	path := filepath.Join("testdata", name+".golden")
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil { //nolint:mnd
+			t.Fatalf("handlertest: %v", err)
+		}
+
+		if err := os.WriteFile(path, got, 0o644); err != nil { //nolint:gosec,mnd
+			t.Fatalf("handlertest: %v", err)
+		}
+
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("handlertest: %v (run the tests with -update-golden to create it)", err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("handlertest: body doesn't match %s\n--- want\n%s--- got\n%s", path, want, got)
+	}
+}
@@ -0,0 +1,151 @@
+package handlertest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/luca-arch/go-goodies/handler"
+	"github.com/luca-arch/go-goodies/handler/handlertest"
+	"github.com/stretchr/testify/assert"
+)
+
+type OrderArgs struct {
+	PK int `in:"pk,path,min=1"`
+}
+
+type Order struct {
+	PK       int    `json:"pk"`
+	Customer string `json:"customer" validate:"required"`
+}
+
+var getOrder = handler.WithArgsOutput(nil, func(_ context.Context, args OrderArgs) (*Order, error) { //nolint:gochecknoglobals
+	if args.PK == 404 { //nolint:mnd
+		return nil, handler.NotFound(errors.New("no such order"))
+	}
+
+	return &Order{PK: args.PK, Customer: "alice"}, nil
+})
+
+var putOrder = handler.New(func(_ context.Context, r *handler.Request[OrderArgs, Order]) (*Order, error) { //nolint:gochecknoglobals
+	r.In.PK = r.Args.PK
+
+	return &r.In, nil
+})
+
+// recorder records the failures reported by the assertions.
+type recorder struct {
+	failures []string
+}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.Errorf(format, args...)
+}
+
+func (r *recorder) Helper() {}
+
+func TestDo(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		args  OrderArgs
+		check func(t *testing.T, res *handlertest.Result[*Order])
+	}{
+		"output": {
+			args: OrderArgs{PK: 7},
+			check: func(t *testing.T, res *handlertest.Result[*Order]) {
+				t.Helper()
+
+				res.AssertStatus(t, http.StatusOK).
+					AssertHeader(t, "Content-Type", "application/json").
+					AssertOut(t, &Order{PK: 7, Customer: "alice"}).
+					AssertGolden(t, "order")
+			},
+		},
+		"error": {
+			args: OrderArgs{PK: 404},
+			check: func(t *testing.T, res *handlertest.Result[*Order]) {
+				t.Helper()
+
+				res.AssertError(t, http.StatusNotFound, "no such order")
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			test.check(t, handlertest.Do[*Order](t, getOrder, http.MethodGet, "/orders/{pk}", test.args, struct{}{}))
+		})
+	}
+
+	t.Run("absolute pattern", func(t *testing.T) {
+		t.Parallel()
+
+		handlertest.Do[*Order](t, getOrder, http.MethodGet, "https://shop.example/orders/{pk}?v=1", OrderArgs{PK: 7}, struct{}{}).
+			AssertOut(t, &Order{PK: 7, Customer: "alice"})
+	})
+}
+
+func TestDoWithBody(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		args  OrderArgs
+		in    Order
+		check func(t *testing.T, res *handlertest.Result[*Order])
+	}{
+		"body": {
+			args: OrderArgs{PK: 3},
+			in:   Order{PK: 0, Customer: "bob"},
+			check: func(t *testing.T, res *handlertest.Result[*Order]) {
+				t.Helper()
+
+				res.AssertOut(t, &Order{PK: 3, Customer: "bob"})
+			},
+		},
+		"field errors": {
+			args: OrderArgs{PK: 3},
+			in:   Order{PK: 0, Customer: ""},
+			check: func(t *testing.T, res *handlertest.Result[*Order]) {
+				t.Helper()
+
+				res.AssertStatus(t, http.StatusBadRequest).AssertFieldError(t, "customer", handler.CodeRequired)
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			test.check(t, handlertest.Do[*Order](t, putOrder, http.MethodPut, "/orders/{pk}", test.args, test.in))
+		})
+	}
+}
+
+func TestAssertionFailures(t *testing.T) {
+	t.Parallel()
+
+	rec := &recorder{failures: nil}
+
+	handlertest.Do[*Order](t, getOrder, http.MethodGet, "/orders/{pk}", OrderArgs{PK: 404}, struct{}{}).
+		AssertStatus(rec, http.StatusOK).
+		AssertOut(rec, &Order{PK: 404, Customer: "alice"}).
+		AssertFieldError(rec, "pk", handler.CodeInvalid).
+		AssertGolden(rec, "order")
+
+	assert.Equal(t, []string{
+		"handlertest: status is 404, want 200\n{\"error\":\"no such order\"}\n",
+		"handlertest: response is an error (404): no such order",
+		"handlertest: no invalid error for field pk\n{\"error\":\"no such order\"}\n",
+		"handlertest: body doesn't match testdata/order.golden\n--- want\n{\n  \"pk\": 7,\n  \"customer\": \"alice\"\n}\n--- got\n{\n  \"error\": \"no such order\"\n}\n",
+	}, rec.failures)
+}
@@ -5,11 +5,23 @@ import (
 	"net/http"
 	"net/url"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
+// Sources that InputFromRequest can read values from. The query string is the default one.
+// Form values are available once the request's body has been parsed, eg. by FormDecoder.
+const (
+	sourceCookie = "cookie"
+	sourceForm   = "form"
+	sourceHeader = "header"
+	sourcePath   = "path"
+	sourceQuery  = "query"
+)
+
 // HydratePointer sets the pointer's value based on its type and the queryValue.
 func HydratePointer(fieldValue *reflect.Value, field *reflect.StructField, tagName, queryValue string) error {
 	fieldType := field.Type
@@ -63,6 +75,46 @@ func HydratePointer(fieldValue *reflect.Value, field *reflect.StructField, tagNa
 	return nil
 }
 
+// HydrateSlice sets the slice's elements based on their type and the queryValues.
+// Empty values are skipped, and the field is left nil when no values are provided.
+func HydrateSlice(fieldValue *reflect.Value, tagName string, queryValues []string) error {
+	fieldType := fieldValue.Type()
+	sliceType := fieldType
+
+	if fieldType.Kind() == reflect.Ptr {
+		sliceType = fieldType.Elem()
+	}
+
+	sliceValue := reflect.MakeSlice(sliceType, 0, len(queryValues))
+
+	for _, queryValue := range queryValues {
+		if queryValue == "" {
+			continue
+		}
+
+		elemValue := reflect.New(sliceType.Elem()).Elem()
+
+		if err := HydrateValue(&elemValue, tagName, queryValue); err != nil {
+			return err
+		}
+
+		sliceValue = reflect.Append(sliceValue, elemValue)
+	}
+
+	switch {
+	case sliceValue.Len() == 0:
+		fieldValue.Set(reflect.Zero(fieldType))
+	case fieldType.Kind() == reflect.Ptr:
+		ptrValue := reflect.New(sliceType)
+		ptrValue.Elem().Set(sliceValue)
+		fieldValue.Set(ptrValue)
+	default:
+		fieldValue.Set(sliceValue)
+	}
+
+	return nil
+}
+
 // HydrateValue sets the value based on its type and the queryValue.
 func HydrateValue(fieldValue *reflect.Value, tagName, queryValue string) error {
 	switch fieldValue.Kind() { //nolint:exhaustive
@@ -118,23 +170,176 @@ func HydrateValue(fieldValue *reflect.Value, tagName, queryValue string) error {
 	return nil
 }
 
-// InputFromRequest hydrates a struct reading from the request args and path.
+// InputFromRequest hydrates a struct reading from the request args, path, headers and cookies.
+// All the fields are read before returning, and their failures are reported together as FieldErrors.
 // Behaviour is defined via struct tags, eg:
 //   - `in:"pk,path,required"` will search for the pathvalue named pk, and return an error if not found.
 //   - `in:"job_id,omitempty"` will search for the query arg named job_id, allowing it to be empty.
+//   - `in:"X-Tenant-Id,header,required"` will search for the header named X-Tenant-Id, and return an error if not found.
+//   - `in:"session,cookie"` will search for the cookie named session.
+//   - `in:"name,form"` will search for the form value named name, in a request's body that has been parsed already.
+//   - `in:"tag"` on a slice field will collect every query arg named tag (eg. ?tag=a&tag=b).
+//   - `in:"ids,explode=false"` on a slice field will also split values on commas (eg. ?ids=1,2,3).
+//   - `in:"limit,min=1,max=100"` will validate the value once read, see Validate for the available rules.
 func InputFromRequest[T any](r *http.Request) (T, error) { //nolint:ireturn
+	var in T
+
+	err := bindRequest(reflect.ValueOf(&in).Elem(), r, sourceQuery)
+
+	return in, err
+}
+
+// bindRequest hydrates the struct inValue as described by InputFromRequest.
+// Fields that don't specify a source in their tag are read from defaultSource.
+func bindRequest(inValue reflect.Value, r *http.Request, defaultSource string) error {
 	var (
-		err error
-		in  T
+		errs  bindErrors
+		query url.Values
 	)
 
-	// Get the reflect.Value of the struct
-	inValue := reflect.ValueOf(&in).Elem()
-	inType := inValue.Type()
+	// Most structs have a few fields to check, which then don't need to be allocated.
+	checks := make([]*fieldPlan, 0, 8) //nolint:mnd
+
+	plan := planFor(inValue.Type(), defaultSource)
+
+	if plan.query {
+		query = r.URL.Query()
+	}
+
+	for i := range plan.fields {
+		field := &plan.fields[i]
+
+		queryValues := valuesFromRequest(r, query, field.source, field.name)
+
+		if !field.explode {
+			queryValues = splitValues(queryValues)
+		}
+
+		queryValue := ""
+		if len(queryValues) > 0 {
+			queryValue = queryValues[0]
+		}
+
+		// Handle required fields.
+		if queryValue == "" {
+			if field.required {
+				errs.fail(field.onErr, &FieldError{Field: field.name, Source: field.source, Code: CodeRequired, Message: "missing required field: " + field.name})
+
+				continue
+			}
+
+			if field.omitEmpty {
+				continue
+			}
+		}
+
+		// Set the field value.
+		fieldValue := inValue.Field(field.index)
+
+		if err := field.convert(fieldValue, field, queryValues); err != nil {
+			errs.fail(field.onErr, &FieldError{Field: field.name, Source: field.source, Code: CodeInvalid, Message: err.Error()})
+
+			continue
+		}
+
+		// Validation rules apply to the values that were provided.
+		// The required option has already been checked against the request.
+		if len(field.rules) > 0 && queryValue != "" {
+			checks = append(checks, field)
+		}
+	}
+
+	for _, check := range checks {
+		if fieldErr := checkRules(inValue, inValue.Field(check.index), check.name, check.rules); fieldErr != nil {
+			fieldErr.Source = check.source
+			errs.fail(check.onErr, fieldErr)
+		}
+	}
+
+	if validator, ok := inValue.Addr().Interface().(Validator); ok && len(errs.fieldErrs) == 0 {
+		if err := validator.Validate(); err != nil {
+			for _, fieldErr := range toFieldErrors(err, "", "") {
+				errs.fail(ErrInvalidInput, fieldErr)
+			}
+		}
+	}
+
+	return errs.err()
+}
+
+// bindErrors gathers the field errors found by bindRequest, along with the sentinel errors they are reported as.
+type bindErrors struct {
+	fieldErrs FieldErrors
+	onErrs    []error
+}
+
+// fail records a field error along with the sentinel error it is reported as.
+func (e *bindErrors) fail(onErr error, fieldErr *FieldError) {
+	if !slices.Contains(e.onErrs, onErr) {
+		e.onErrs = append(e.onErrs, onErr)
+	}
+
+	e.fieldErrs = append(e.fieldErrs, fieldErr)
+}
+
+// err returns the field errors joined with their sentinel errors, or nil if there is none.
+func (e *bindErrors) err() error {
+	if len(e.fieldErrs) == 0 {
+		return nil
+	}
+
+	return errors.Join(append(e.onErrs, e.fieldErrs)...)
+}
 
-	// Iterate over all the fields of the struct
-	for i := 0; i < inType.NumField(); i++ {
-		field := inType.Field(i)
+// bindingPlans caches the binding plan of each struct type read by bindRequest, so that tags are parsed only once.
+var bindingPlans sync.Map //nolint:gochecknoglobals // map[planKey]*bindingPlan
+
+// planKey identifies a binding plan. The same struct is bound differently depending on its default source.
+type planKey struct {
+	t             reflect.Type
+	defaultSource string
+}
+
+// bindingPlan lists the fields of a struct read by bindRequest, and whether any of them is read from the query string.
+type bindingPlan struct {
+	fields []fieldPlan
+	query  bool
+}
+
+// fieldPlan describes how a struct field is read by bindRequest, as parsed from its `in` tag.
+// Convert hydrates the field from the values found in the request.
+type fieldPlan struct {
+	index     int
+	field     reflect.StructField
+	name      string
+	source    string
+	onErr     error
+	required  bool
+	omitEmpty bool
+	explode   bool
+	rules     []rule
+	convert   func(fieldValue reflect.Value, field *fieldPlan, values []string) error
+}
+
+// planFor returns the binding plan of t, compiling it on first use.
+func planFor(t reflect.Type, defaultSource string) *bindingPlan {
+	key := planKey{t: t, defaultSource: defaultSource}
+
+	if plan, ok := bindingPlans.Load(key); ok {
+		return plan.(*bindingPlan) //nolint:forcetypeassert
+	}
+
+	plan, _ := bindingPlans.LoadOrStore(key, compilePlan(t, defaultSource))
+
+	return plan.(*bindingPlan) //nolint:forcetypeassert
+}
+
+// compilePlan parses the `in` tags of the fields of t.
+func compilePlan(t reflect.Type, defaultSource string) *bindingPlan {
+	plan := &bindingPlan{fields: nil, query: false}
+
+	for i := range t.NumField() {
+		field := t.Field(i)
 		tag := field.Tag.Get("in")
 
 		// Skip the field if there is no "in" tag
@@ -142,67 +347,112 @@ func InputFromRequest[T any](r *http.Request) (T, error) { //nolint:ireturn
 			continue
 		}
 
-		var queryValue string
-
-		onErr := ErrInvalidInput
-
 		// Parse tag options
 		tagParts := strings.Split(tag, ",")
-		tagName := tagParts[0]
-		isRequired := false
-		omitEmpty := false
-		inPath := false
+		fp := fieldPlan{
+			index:     i,
+			field:     field,
+			name:      tagParts[0],
+			source:    defaultSource,
+			onErr:     ErrInvalidInput,
+			required:  false,
+			omitEmpty: false,
+			explode:   true,
+			rules:     slices.DeleteFunc(parseRules(tagParts[1:]), func(r rule) bool { return r.name == ruleRequired }),
+			convert:   convertValue,
+		}
 
 		for _, option := range tagParts[1:] {
 			switch option {
-			case "path":
-				inPath = true
-				onErr = ErrInvalidArg
+			case sourcePath:
+				fp.source = sourcePath
+				fp.onErr = ErrInvalidArg
+			case sourceCookie, sourceForm, sourceHeader, sourceQuery:
+				fp.source = option
 			case "required":
-				isRequired = true
+				fp.required = true
 			case "omitempty":
-				omitEmpty = true
+				fp.omitEmpty = true
+			case "explode=false":
+				fp.explode = false
 			}
 		}
 
-		if inPath {
-			// Get the value from the path.
-			queryValue = r.PathValue(tagName)
-		} else {
-			// Get the value from the URL query parameters.
-			queryValue = r.URL.Query().Get(tagName)
+		switch {
+		case isSlice(field.Type):
+			fp.convert = convertSlice
+		case field.Type.Kind() == reflect.Ptr:
+			fp.convert = convertPointer
 		}
 
-		// Handle required fields.
-		if queryValue == "" {
-			if isRequired {
-				return in, errors.Join(
-					onErr,
-					errors.New("missing required field: "+tagName), //nolint:err113
-				)
-			}
+		plan.query = plan.query || fp.source == sourceQuery
+		plan.fields = append(plan.fields, fp)
+	}
 
-			if omitEmpty {
-				continue
-			}
-		}
+	return plan
+}
 
-		// Set the field value.
-		fieldValue := inValue.Field(i)
-		switch fieldValue.Kind() { //nolint:exhaustive // The default should cover enough.
-		case reflect.Ptr:
-			err = HydratePointer(&fieldValue, &field, tagName, queryValue)
-		default:
-			err = HydrateValue(&fieldValue, tagName, queryValue)
-		}
+func convertSlice(fieldValue reflect.Value, field *fieldPlan, values []string) error {
+	return HydrateSlice(&fieldValue, field.name, values)
+}
 
-		if err != nil {
-			return in, errors.Join(
-				onErr,
-				err,
-			)
-		}
+func convertPointer(fieldValue reflect.Value, field *fieldPlan, values []string) error {
+	return HydratePointer(&fieldValue, &field.field, field.name, firstValue(values))
+}
+
+func convertValue(fieldValue reflect.Value, field *fieldPlan, values []string) error {
+	return HydrateValue(&fieldValue, field.name, firstValue(values))
+}
+
+// firstValue returns the first of the values, or an empty string if there is none.
+func firstValue(values []string) string {
+	if len(values) == 0 {
+		return ""
 	}
 
-	return in, nil
+	return values[0]
+}
+
+// isSlice reports whether t is a slice (other than []byte) or a pointer to one.
+func isSlice(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8
+}
+
+// splitValues splits comma-separated values, as sent when a list is not exploded (eg. ?ids=1,2,3).
+func splitValues(values []string) []string {
+	out := make([]string, 0, len(values))
+
+	for _, v := range values {
+		out = append(out, strings.Split(v, ",")...)
+	}
+
+	return out
+}
+
+// valuesFromRequest returns all the values named name, reading them from the given source of the request.
+// The query string is passed already parsed.
+func valuesFromRequest(r *http.Request, query url.Values, source, name string) []string {
+	switch source {
+	case sourcePath:
+		return []string{r.PathValue(name)}
+	case sourceHeader:
+		return r.Header.Values(name)
+	case sourceCookie:
+		cookies := r.CookiesNamed(name)
+		values := make([]string, len(cookies))
+
+		for i, cookie := range cookies {
+			values[i] = cookie.Value
+		}
+
+		return values
+	case sourceForm:
+		return r.PostForm[name]
+	default:
+		return query[name]
+	}
 }
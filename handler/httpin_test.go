@@ -3,10 +3,13 @@ package handler_test
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/luca-arch/go-goodies/handler"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type StructInt struct {
@@ -29,6 +32,30 @@ type StructRequired struct {
 	Param string `in:"sentence,required"`
 }
 
+type StructSlices struct {
+	Tags  []string    `in:"tag"`
+	IDs   []int64     `in:"ids,explode=false"`
+	Dates []time.Time `in:"date"`
+	Pages *[]int      `in:"page"`
+	None  *[]int      `in:"none"`
+}
+
+type StructRules struct {
+	Limit  int       `in:"limit,min=1,max=100"`
+	Status string    `in:"status,oneof=open|closed"`
+	Code   string    `in:"code,pattern=^[a-z]+$,maxlen=5"`
+	From   time.Time `in:"from"`
+	To     time.Time `in:"to,gtefield=From"`
+	PK     int       `in:"pk,path,min=1"`
+}
+
+type StructSources struct {
+	Tenant  string `in:"X-Tenant-Id,header,required"`
+	Retries *int   `in:"X-Retries,header"`
+	Session string `in:"session,cookie,required"`
+	Query   string `in:"q,query"`
+}
+
 func TestInputFromRequest(t *testing.T) {
 	t.Parallel()
 
@@ -42,7 +69,9 @@ func TestInputFromRequest(t *testing.T) {
 	)
 
 	type args struct {
-		url string
+		cookies []*http.Cookie
+		headers map[string]string
+		url     string
 	}
 
 	type fields struct {
@@ -125,6 +154,172 @@ func TestInputFromRequest(t *testing.T) {
 				err: "invalid input\nmissing required field: sentence",
 			},
 		},
+		"ok - struct with slices": {
+			args{
+				url: "https://example.com/?tag=a&tag=b&ids=1,2&ids=3&date=2024-01-02T03:04:05Z&page=1&page=2",
+			},
+			fields{
+				call: func(r *http.Request) (any, error) {
+					return handler.InputFromRequest[StructSlices](r)
+				},
+			},
+			wants{
+				out: StructSlices{
+					Tags:  []string{"a", "b"},
+					IDs:   []int64{1, 2, 3},
+					Dates: []time.Time{time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+					Pages: &[]int{1, 2},
+					None:  nil,
+				},
+			},
+		},
+		"error - struct with invalid slice element": {
+			args{
+				url: "https://example.com/?ids=1,two",
+			},
+			fields{
+				call: func(r *http.Request) (any, error) {
+					return handler.InputFromRequest[StructSlices](r)
+				},
+			},
+			wants{
+				err: "invalid input\ninvalid number for field: ids",
+			},
+		},
+		"ok - struct with validation rules": {
+			args{
+				url: "https://example.com/?limit=100&status=open&code=abc&from=2024-01-01T00:00:00Z&to=2024-01-01T00:00:00Z",
+			},
+			fields{
+				call: func(r *http.Request) (any, error) {
+					return handler.InputFromRequest[StructRules](r)
+				},
+			},
+			wants{
+				out: StructRules{
+					Limit:  100,
+					Status: "open",
+					Code:   "abc",
+					From:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+					To:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				},
+			},
+		},
+		"error - struct with value out of range": {
+			args{
+				url: "https://example.com/?limit=101",
+			},
+			fields{
+				call: func(r *http.Request) (any, error) {
+					return handler.InputFromRequest[StructRules](r)
+				},
+			},
+			wants{
+				err: "invalid input\nfield limit must be at most 100",
+			},
+		},
+		"error - struct with value not in enum": {
+			args{
+				url: "https://example.com/?status=pending",
+			},
+			fields{
+				call: func(r *http.Request) (any, error) {
+					return handler.InputFromRequest[StructRules](r)
+				},
+			},
+			wants{
+				err: "invalid input\nfield status must be one of: open, closed",
+			},
+		},
+		"error - struct with value not matching pattern": {
+			args{
+				url: "https://example.com/?code=ABC",
+			},
+			fields{
+				call: func(r *http.Request) (any, error) {
+					return handler.InputFromRequest[StructRules](r)
+				},
+			},
+			wants{
+				err: "invalid input\nfield code must match the pattern ^[a-z]+$",
+			},
+		},
+		"error - struct with invalid range": {
+			args{
+				url: "https://example.com/?from=2024-01-02T00:00:00Z&to=2024-01-01T00:00:00Z",
+			},
+			fields{
+				call: func(r *http.Request) (any, error) {
+					return handler.InputFromRequest[StructRules](r)
+				},
+			},
+			wants{
+				err: "invalid input\nfield to must be greater than or equal to From",
+			},
+		},
+		"ok - struct with headers and cookies": {
+			args{
+				cookies: []*http.Cookie{{Name: "session", Value: "abc"}},
+				headers: map[string]string{"X-Tenant-Id": "acme", "X-Retries": "10"},
+				url:     "https://example.com/?q=my+string",
+			},
+			fields{
+				call: func(r *http.Request) (any, error) {
+					return handler.InputFromRequest[StructSources](r)
+				},
+			},
+			wants{
+				out: StructSources{
+					Tenant:  "acme",
+					Retries: &intNum,
+					Session: "abc",
+					Query:   strVal,
+				},
+			},
+		},
+		"error - struct with required header": {
+			args{
+				cookies: []*http.Cookie{{Name: "session", Value: "abc"}},
+				url:     "https://example.com/",
+			},
+			fields{
+				call: func(r *http.Request) (any, error) {
+					return handler.InputFromRequest[StructSources](r)
+				},
+			},
+			wants{
+				err: "invalid input\nmissing required field: X-Tenant-Id",
+			},
+		},
+		"error - struct with required cookie": {
+			args{
+				headers: map[string]string{"X-Tenant-Id": "acme"},
+				url:     "https://example.com/",
+			},
+			fields{
+				call: func(r *http.Request) (any, error) {
+					return handler.InputFromRequest[StructSources](r)
+				},
+			},
+			wants{
+				err: "invalid input\nmissing required field: session",
+			},
+		},
+		"error - struct with invalid header": {
+			args{
+				cookies: []*http.Cookie{{Name: "session", Value: "abc"}},
+				headers: map[string]string{"X-Tenant-Id": "acme", "X-Retries": "many"},
+				url:     "https://example.com/",
+			},
+			fields{
+				call: func(r *http.Request) (any, error) {
+					return handler.InputFromRequest[StructSources](r)
+				},
+			},
+			wants{
+				err: "invalid input\ninvalid integer value for field: X-Retries",
+			},
+		},
 	}
 
 	for name, test := range tests {
@@ -133,6 +328,14 @@ func TestInputFromRequest(t *testing.T) {
 
 			r := httptest.NewRequest(http.MethodGet, test.args.url, nil)
 
+			for k, v := range test.args.headers {
+				r.Header.Set(k, v)
+			}
+
+			for _, c := range test.args.cookies {
+				r.AddCookie(c)
+			}
+
 			out, err := test.fields.call(r)
 
 			if test.wants.err != "" {
@@ -145,3 +348,81 @@ func TestInputFromRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestInputFromRequestFieldErrors(t *testing.T) {
+	t.Parallel()
+
+	r := httptest.NewRequest(http.MethodGet, "https://example.com/?limit=0&status=pending&ids=x", nil)
+
+	_, err := handler.InputFromRequest[struct {
+		Limit  int    `in:"limit,min=1"`
+		Status string `in:"status,oneof=open|closed"`
+		IDs    []int  `in:"ids"`
+		Tenant string `in:"X-Tenant-Id,header,required"`
+	}](r)
+
+	var fieldErrs handler.FieldErrors
+
+	require.ErrorAs(t, err, &fieldErrs)
+	require.ErrorIs(t, err, handler.ErrInvalidInput)
+	assert.Equal(t, handler.FieldErrors{
+		{Field: "ids", Source: "query", Code: "invalid", Message: "invalid number for field: ids"},
+		{Field: "X-Tenant-Id", Source: "header", Code: "required", Message: "missing required field: X-Tenant-Id"},
+		{Field: "limit", Source: "query", Code: "min", Message: "field limit must be at least 1"},
+		{Field: "status", Source: "query", Code: "oneof", Message: "field status must be one of: open, closed"},
+	}, fieldErrs)
+}
+
+func TestInputFromRequestDefaultSource(t *testing.T) {
+	t.Parallel()
+
+	type Search struct {
+		Query string `in:"q"`
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/?q=from-query", strings.NewReader("q=from-form"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	// The same struct is read from the query string or from the form, depending on how it is bound.
+	for range 2 {
+		var form Search
+
+		query, err := handler.InputFromRequest[Search](r)
+		require.NoError(t, err)
+		require.NoError(t, handler.FormDecoder{}.Decode(r, &form))
+
+		assert.Equal(t, "from-query", query.Query)
+		assert.Equal(t, "from-form", form.Query)
+	}
+}
+
+func BenchmarkInputFromRequest(b *testing.B) {
+	r := httptest.NewRequest(http.MethodGet, "https://example.com/orders/7?limit=10&status=open&code=abc&from=2024-01-01T00:00:00Z&to=2024-02-01T00:00:00Z&tag=a&tag=b&ids=1,2,3&q=pens", nil)
+	r.SetPathValue("pk", "7")
+	r.Header.Set("X-Tenant-Id", "acme")
+	r.AddCookie(&http.Cookie{Name: "session", Value: "s3cr3t"}) //nolint:exhaustruct
+
+	benchmarks := map[string]func(*http.Request) (any, error){
+		"rules": func(r *http.Request) (any, error) {
+			return handler.InputFromRequest[StructRules](r)
+		},
+		"slices": func(r *http.Request) (any, error) {
+			return handler.InputFromRequest[StructSlices](r)
+		},
+		"sources": func(r *http.Request) (any, error) {
+			return handler.InputFromRequest[StructSources](r)
+		},
+	}
+
+	for name, call := range benchmarks {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			for range b.N {
+				if _, err := call(r); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"errors"
+	"sync"
+)
+
+// Exposure tells whether the message of a mapped error is served to clients.
+type Exposure int
+
+const (
+	// ExposeMessage serves the error message as is.
+	ExposeMessage Exposure = iota
+	// HideMessage replaces the error message with the generic text of the HTTP status.
+	HideMessage
+)
+
+// DefaultErrorMapper is the ErrorMapper consulted by the handlers that don't use the ErrorMapping option.
+var DefaultErrorMapper = NewErrorMapper() //nolint:gochecknoglobals
+
+// ErrorMapper maps errors to HTTP statuses. Mappings are checked in the same order they were registered.
+type ErrorMapper struct {
+	mu       sync.RWMutex
+	mappings []errorMapping
+}
+
+type errorMapping struct {
+	match    func(error) bool
+	status   int
+	exposure Exposure
+}
+
+// NewErrorMapper returns an empty ErrorMapper.
+func NewErrorMapper() *ErrorMapper {
+	return &ErrorMapper{
+		mu:       sync.RWMutex{},
+		mappings: nil,
+	}
+}
+
+// MapError registers a mapping on the DefaultErrorMapper, see ErrorMapper.Map.
+func MapError(target error, status int, exposure Exposure) {
+	DefaultErrorMapper.Map(target, status, exposure)
+}
+
+// MapErrorFunc registers a mapping on the DefaultErrorMapper, see ErrorMapper.MapFunc.
+func MapErrorFunc(match func(error) bool, status int, exposure Exposure) {
+	DefaultErrorMapper.MapFunc(match, status, exposure)
+}
+
+// Map serves the errors matching target (as per errors.Is) with the given HTTP status.
+func (m *ErrorMapper) Map(target error, status int, exposure Exposure) *ErrorMapper {
+	return m.MapFunc(func(err error) bool { return errors.Is(err, target) }, status, exposure)
+}
+
+// MapFunc serves the errors for which match returns true with the given HTTP status.
+func (m *ErrorMapper) MapFunc(match func(error) bool, status int, exposure Exposure) *ErrorMapper {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.mappings = append(m.mappings, errorMapping{match: match, status: status, exposure: exposure})
+
+	return m
+}
+
+// Lookup returns the HTTP status and exposure of the first mapping matching err.
+// The last value is false when no mapping matches.
+func (m *ErrorMapper) Lookup(err error) (int, Exposure, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, mapping := range m.mappings {
+		if mapping.match(err) {
+			return mapping.status, mapping.exposure, true
+		}
+	}
+
+	return 0, ExposeMessage, false
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/luca-arch/go-goodies/logger"
+)
+
+// AccessLog logs every request once served, along with the status, size and latency of the response.
+// Server errors are logged at the error level, everything else at the info level. A nil logger logs nothing.
+// Placed after RequestID, it logs the ID of the request too.
+func AccessLog(l *slog.Logger) Middleware {
+	if l == nil {
+		l = logger.NewNop()
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rw := NewResponseWriter(w)
+
+			next.ServeHTTP(rw, r)
+
+			status := rw.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			level := slog.LevelInfo
+			if status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+
+			l.LogAttrs(r.Context(), level, "HTTP request served",
+				slog.String("http.method", r.Method),
+				slog.String("http.url", r.URL.String()),
+				slog.Int("http.status", status),
+				slog.Int64("http.bytes", rw.BytesWritten()),
+				slog.Duration("latency", time.Since(start)),
+				slog.String("request_id", RequestIDFromContext(r.Context())),
+			)
+		})
+	}
+}
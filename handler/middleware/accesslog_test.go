@@ -0,0 +1,72 @@
+package middleware_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/luca-arch/go-goodies/handler"
+	"github.com/luca-arch/go-goodies/handler/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessLog(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		err    error
+		level  string
+		status float64
+		bytes  float64
+	}{
+		"ok": {
+			err:    nil,
+			level:  "INFO",
+			status: http.StatusOK,
+			bytes:  17,
+		},
+		"server error": {
+			err:    errors.New("boom"),
+			level:  "ERROR",
+			status: http.StatusInternalServerError,
+			bytes:  17,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				logs  bytes.Buffer
+				entry map[string]any
+			)
+
+			h := handler.New(func(_ context.Context, _ *handler.Request[struct{}, struct{}]) (map[string]bool, error) {
+				return map[string]bool{"success": true}, test.err
+			}, handler.Use(middleware.RequestID(), middleware.AccessLog(slog.New(slog.NewJSONHandler(&logs, nil)))))
+
+			r := httptest.NewRequest(http.MethodGet, "/items?page=2", nil)
+			r.Header.Set(middleware.HeaderRequestID, "abc123")
+
+			h.ServeHTTP(httptest.NewRecorder(), r)
+
+			require.NoError(t, json.Unmarshal(logs.Bytes(), &entry))
+
+			assert.Equal(t, test.level, entry["level"])
+			assert.Equal(t, "HTTP request served", entry["msg"])
+			assert.Equal(t, "GET", entry["http.method"])
+			assert.Equal(t, "/items?page=2", entry["http.url"])
+			assert.InDelta(t, test.status, entry["http.status"], 0)
+			assert.InDelta(t, test.bytes, entry["http.bytes"], 0)
+			assert.Equal(t, "abc123", entry["request_id"])
+			assert.Contains(t, entry, "latency")
+		})
+	}
+}
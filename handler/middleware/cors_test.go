@@ -0,0 +1,105 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/luca-arch/go-goodies/handler/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORS(t *testing.T) {
+	t.Parallel()
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := map[string]struct {
+		opts    middleware.CORSOptions
+		method  string
+		header  http.Header
+		status  int
+		headers map[string]string
+	}{
+		"same origin": {
+			opts:    middleware.CORSOptions{AllowedOrigins: []string{"*"}},
+			method:  http.MethodGet,
+			header:  http.Header{},
+			status:  http.StatusOK,
+			headers: map[string]string{"Access-Control-Allow-Origin": "", "Vary": "Origin"},
+		},
+		"any origin": {
+			opts:   middleware.CORSOptions{AllowedOrigins: []string{"*"}, ExposedHeaders: []string{"X-Request-Id"}},
+			method: http.MethodGet,
+			header: http.Header{"Origin": {"https://app.example.com"}},
+			status: http.StatusOK,
+			headers: map[string]string{
+				"Access-Control-Allow-Origin":   "*",
+				"Access-Control-Expose-Headers": "X-Request-Id",
+			},
+		},
+		"credentials": {
+			opts:   middleware.CORSOptions{AllowedOrigins: []string{"https://app.example.com"}, AllowCredentials: true},
+			method: http.MethodGet,
+			header: http.Header{"Origin": {"https://app.example.com"}},
+			status: http.StatusOK,
+			headers: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Allow-Credentials": "true",
+			},
+		},
+		"disallowed origin": {
+			opts:    middleware.CORSOptions{AllowedOrigins: []string{"https://app.example.com"}},
+			method:  http.MethodGet,
+			header:  http.Header{"Origin": {"https://evil.example.com"}},
+			status:  http.StatusOK,
+			headers: map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		"preflight": {
+			opts: middleware.CORSOptions{
+				AllowedOrigins: []string{"https://app.example.com"},
+				AllowedMethods: []string{http.MethodGet, http.MethodPut},
+				AllowedHeaders: []string{"Content-Type", "X-Tenant-Id"},
+				MaxAge:         10 * time.Minute,
+			},
+			method: http.MethodOptions,
+			header: http.Header{"Origin": {"https://app.example.com"}, "Access-Control-Request-Method": {"PUT"}},
+			status: http.StatusNoContent,
+			headers: map[string]string{
+				"Access-Control-Allow-Origin":  "https://app.example.com",
+				"Access-Control-Allow-Methods": "GET, PUT",
+				"Access-Control-Allow-Headers": "Content-Type, X-Tenant-Id",
+				"Access-Control-Max-Age":       "600",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(test.method, "/", nil)
+			r.Header = test.header
+
+			w := httptest.NewRecorder()
+			middleware.CORS(test.opts)(next).ServeHTTP(w, r)
+
+			assert.Equal(t, test.status, w.Code)
+
+			for k, v := range test.headers {
+				assert.Equal(t, v, w.Header().Get(k), k)
+			}
+		})
+	}
+}
+
+func TestCORSCredentialsForAnyOrigin(t *testing.T) {
+	t.Parallel()
+
+	assert.Panics(t, func() {
+		middleware.CORS(middleware.CORSOptions{AllowedOrigins: []string{"*"}, AllowCredentials: true})
+	})
+}
@@ -0,0 +1,21 @@
+// package middleware provides HTTP middlewares that can wrap any http.Handler, including the ones created by the handler package.
+// They can be passed to handler.Use and Router.Group as they are.
+package middleware
+
+import (
+	"net/http"
+)
+
+// Middleware wraps an HTTP handler with additional behaviour. It is the same as handler.Middleware.
+type Middleware = func(http.Handler) http.Handler
+
+// Chain combines the middlewares into one. The first one is the outermost.
+func Chain(mws ...Middleware) Middleware {
+	return func(h http.Handler) http.Handler {
+		for i := len(mws) - 1; i >= 0; i-- {
+			h = mws[i](h)
+		}
+
+		return h
+	}
+}
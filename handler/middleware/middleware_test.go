@@ -0,0 +1,94 @@
+package middleware_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/luca-arch/go-goodies/handler/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChain(t *testing.T) {
+	t.Parallel()
+
+	var order []string
+
+	trace := func(name string) middleware.Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	h := middleware.Chain(trace("outer"), trace("inner"))(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+		order = append(order, "handler")
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, []string{"outer", "inner", "handler"}, order)
+}
+
+func TestResponseWriter(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		handler http.HandlerFunc
+		status  int
+		bytes   int64
+		flushed bool
+	}{
+		"nothing written": {
+			handler: func(_ http.ResponseWriter, _ *http.Request) {},
+			status:  0,
+			bytes:   0,
+			flushed: false,
+		},
+		"implicit status": {
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = io.WriteString(w, "hello")
+			},
+			status:  http.StatusOK,
+			bytes:   5,
+			flushed: false,
+		},
+		"explicit status": {
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusCreated)
+				_, _ = io.WriteString(w, "{}")
+			},
+			status:  http.StatusCreated,
+			bytes:   2,
+			flushed: false,
+		},
+		"flushed": {
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = io.WriteString(w, "data: 1\n\n")
+				_ = http.NewResponseController(w).Flush()
+			},
+			status:  http.StatusOK,
+			bytes:   9,
+			flushed: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := httptest.NewRecorder()
+			w := middleware.NewResponseWriter(rec)
+
+			test.handler(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, test.status, w.Status())
+			assert.Equal(t, test.bytes, w.BytesWritten())
+			assert.Equal(t, test.flushed, rec.Flushed)
+			assert.Same(t, w, middleware.NewResponseWriter(w))
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// HeaderRequestID is the header carrying the ID of a request.
+const HeaderRequestID = "X-Request-Id"
+
+// maxRequestIDLen is the maximum length of the IDs sent by clients.
+const maxRequestIDLen = 128
+
+type requestIDKey struct{}
+
+// RequestID makes sure that every request has an ID: the one sent by the client or a proxy in the X-Request-Id header,
+// or a new random one. IDs longer than 128 characters, or with characters other than letters, digits, '-', '_', '.' and ':',
+// are replaced by a new one. The ID is set on a copy of the request's header, so that the error responses of the handler
+// package report it as their correlation ID, on the response's header, and in the context (see RequestIDFromContext).
+func RequestID() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(HeaderRequestID)
+			if !ValidRequestID(id) {
+				id = newRequestID()
+			}
+
+			r = r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id))
+			r.Header = r.Header.Clone()
+			r.Header.Set(HeaderRequestID, id)
+
+			w.Header().Set(HeaderRequestID, id)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequestIDFromContext returns the ID of the request set by RequestID, or an empty string.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+
+	return id
+}
+
+// newRequestID returns a random ID of 128 bits.
+func newRequestID() string {
+	b := make([]byte, 16) //nolint:mnd
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+// ValidRequestID tells whether an ID sent by the client is safe to log and echo back: up to 128 letters, digits,
+// '-', '_', '.' and ':'.
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
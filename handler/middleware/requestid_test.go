@@ -0,0 +1,69 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/luca-arch/go-goodies/handler"
+	"github.com/luca-arch/go-goodies/handler/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	t.Parallel()
+
+	h := handler.New(func(ctx context.Context, _ *handler.Request[struct{}, struct{}]) (any, error) {
+		return nil, errors.New("database is down: " + middleware.RequestIDFromContext(ctx))
+	}, handler.SafeErrors(), handler.Use(middleware.RequestID()))
+
+	tests := map[string]struct {
+		header string
+		want   string
+	}{
+		"from the client": {
+			header: "abc123",
+			want:   "abc123",
+		},
+		"generated": {
+			header: "",
+			want:   "",
+		},
+		"too long": {
+			header: strings.Repeat("a", 129),
+			want:   "",
+		},
+		"invalid characters": {
+			header: `abc"}<script>`,
+			want:   "",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.header != "" {
+				r.Header.Set(middleware.HeaderRequestID, test.header)
+			}
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			assert.Equal(t, test.header, r.Header.Get(middleware.HeaderRequestID), "the inbound header is left as it is")
+
+			id := w.Header().Get(middleware.HeaderRequestID)
+			if test.want != "" {
+				assert.Equal(t, test.want, id)
+			} else {
+				assert.Len(t, id, 32)
+			}
+
+			assert.Equal(t, `{"error":"Internal Server Error","correlationId":"`+id+`"}`+"\n", w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Timeout cancels the context of the requests that are not served within d, so that handlers can give up on their work.
+// Unlike http.TimeoutHandler, the response is not buffered and streams keep working: handlers are trusted to return once the
+// context is done. If they do so without writing anything, 503 Service Unavailable is served.
+func Timeout(d time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+
+			rw := NewResponseWriter(w)
+
+			next.ServeHTTP(rw, r.WithContext(ctx))
+
+			if !rw.Written() && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				http.Error(rw, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/luca-arch/go-goodies/handler"
+	"github.com/luca-arch/go-goodies/handler/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeout(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		handler http.Handler
+		status  int
+		want    string
+	}{
+		"in time": {
+			handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			}),
+			status: http.StatusNoContent,
+			want:   "",
+		},
+		"handler gives up": {
+			handler: http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				<-r.Context().Done()
+			}),
+			status: http.StatusServiceUnavailable,
+			want:   "Service Unavailable\n",
+		},
+		"handler serves the error": {
+			handler: handler.New(func(ctx context.Context, _ *handler.Request[struct{}, struct{}]) (any, error) {
+				<-ctx.Done()
+
+				return nil, handler.ServiceUnavailable(ctx.Err(), time.Second)
+			}),
+			status: http.StatusServiceUnavailable,
+			want:   `{"error":"context deadline exceeded"}` + "\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			middleware.Timeout(10*time.Millisecond)(test.handler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, test.status, w.Code)
+			assert.Equal(t, test.want, w.Body.String())
+		})
+	}
+}
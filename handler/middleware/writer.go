@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"net/http"
+)
+
+// ResponseWriter wraps an http.ResponseWriter to record the status and the size of the response.
+// Flushing and the other optional interfaces remain available via http.ResponseController.
+type ResponseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+// NewResponseWriter wraps w, unless it is a ResponseWriter already.
+func NewResponseWriter(w http.ResponseWriter) *ResponseWriter {
+	if rw, ok := w.(*ResponseWriter); ok {
+		return rw
+	}
+
+	return &ResponseWriter{ResponseWriter: w, status: 0, bytes: 0}
+}
+
+// Status returns the status of the response, or 0 if nothing has been written yet.
+func (w *ResponseWriter) Status() int {
+	return w.status
+}
+
+// BytesWritten returns the size of the response's body written so far.
+func (w *ResponseWriter) BytesWritten() int64 {
+	return w.bytes
+}
+
+// Written reports whether the response's headers have been written.
+func (w *ResponseWriter) Written() bool {
+	return w.status != 0
+}
+
+func (w *ResponseWriter) WriteHeader(status int) {
+	// Informational responses can precede the actual one.
+	if w.status == 0 && (status < 100 || status > 199 || status == http.StatusSwitchingProtocols) {
+		w.status = status
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *ResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+
+	return n, err //nolint:wrapcheck
+}
+
+// Flush sends the buffered data to the client, for the handlers that check for http.Flusher.
+func (w *ResponseWriter) Flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns the wrapped http.ResponseWriter, for http.ResponseController.
+func (w *ResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
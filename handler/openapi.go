@@ -0,0 +1,507 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/goccy/go-yaml"
+)
+
+// OpenAPIVersion is the version of the OpenAPI specification the generated documents comply with.
+const OpenAPIVersion = "3.1.0"
+
+var ErrInvalidPattern = errors.New("invalid route pattern")
+
+// wildcard matches the wildcards of the patterns of http.ServeMux, eg. {pk} or {path...}.
+var wildcard = regexp.MustCompile(`{([^}.$]*)(\.\.\.)?}`) //nolint:gochecknoglobals
+
+// Document is an OpenAPI 3.1 document.
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+// Info is the metadata of an API.
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Components holds the schemas referenced by the operations of a Document.
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas,omitempty"`
+}
+
+// PathItem holds the operations of a path, by lowercase HTTP method.
+type PathItem map[string]*Operation
+
+// Operation describes a route of an API.
+type Operation struct {
+	OperationID string                        `json:"operationId,omitempty"`
+	Summary     string                        `json:"summary,omitempty"`
+	Description string                        `json:"description,omitempty"`
+	Tags        []string                      `json:"tags,omitempty"`
+	Deprecated  bool                          `json:"deprecated,omitempty"`
+	Parameters  []*Parameter                  `json:"parameters,omitempty"`
+	RequestBody *RequestBody                  `json:"requestBody,omitempty"`
+	Responses   map[string]*OperationResponse `json:"responses"`
+}
+
+// Parameter describes a value read from the path, query string, headers or cookies of a request.
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Style    string  `json:"style,omitempty"`
+	Explode  *bool   `json:"explode,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody describes the body of a request, by media type.
+type RequestBody struct {
+	Required bool                `json:"required,omitempty"`
+	Content  map[string]*Content `json:"content"`
+}
+
+// OperationResponse describes a response of an operation, by media type.
+type OperationResponse struct {
+	Description string              `json:"description"`
+	Content     map[string]*Content `json:"content,omitempty"`
+}
+
+// Content holds the schema of a body.
+type Content struct {
+	Schema *Schema `json:"schema"`
+}
+
+// handlerTypes are the types an HTTP handler was created with, and its settings.
+type handlerTypes struct {
+	args   reflect.Type
+	in     reflect.Type
+	out    reflect.Type
+	stream string
+	cfg    *config
+}
+
+// typedHandler is an HTTP handler that remembers the types it was created with, so that it can be documented.
+type typedHandler struct {
+	http.Handler
+	types handlerTypes
+}
+
+// DocOption documents a route beyond what the types of its handler tell.
+type DocOption func(*routeDoc)
+
+// routeDoc holds the documentation of a route that cannot be found in the types of its handler.
+type routeDoc struct {
+	operationID string
+	summary     string
+	description string
+	tags        []string
+	deprecated  bool
+	status      int
+	errors      []int
+}
+
+// Deprecated marks the route as deprecated.
+func Deprecated() DocOption {
+	return func(d *routeDoc) {
+		d.deprecated = true
+	}
+}
+
+// Description sets the description of the route, which can span multiple lines.
+func Description(description string) DocOption {
+	return func(d *routeDoc) {
+		d.description = description
+	}
+}
+
+// ErrorStatus documents the error statuses the route can be served with, besides 400 for invalid inputs and 500.
+func ErrorStatus(statuses ...int) DocOption {
+	return func(d *routeDoc) {
+		d.errors = append(d.errors, statuses...)
+	}
+}
+
+// OperationID sets the unique name of the route, which client generators use to name their methods.
+func OperationID(id string) DocOption {
+	return func(d *routeDoc) {
+		d.operationID = id
+	}
+}
+
+// SuccessStatus documents the status of the successful responses, when it is not 200 (eg. the handler returns a Response).
+func SuccessStatus(status int) DocOption {
+	return func(d *routeDoc) {
+		d.status = status
+	}
+}
+
+// Summary sets the short summary of the route.
+func Summary(summary string) DocOption {
+	return func(d *routeDoc) {
+		d.summary = summary
+	}
+}
+
+// Tags groups the route with the others of the same tags.
+func Tags(tags ...string) DocOption {
+	return func(d *routeDoc) {
+		d.tags = append(d.tags, tags...)
+	}
+}
+
+// route is a route recorded by OpenAPI.
+type route struct {
+	method string
+	path   string
+	types  *handlerTypes
+	doc    routeDoc
+}
+
+// OpenAPI records the routes of an API, and generates their OpenAPI 3.1 document from the types of their handlers.
+// Handlers created by New and the With* helpers are fully described: parameters come from the `in` tags of Args,
+// the request's body from In and the response's from Out, along with their validation rules. Other handlers only get their
+// path and method documented. OpenAPI is itself an HTTP handler, serving the document as JSON, or as YAML when the
+// client accepts application/yaml or the path ends in .yaml.
+// It is safe for concurrent use.
+type OpenAPI struct {
+	mu     sync.RWMutex
+	info   Info
+	routes []*route
+}
+
+// NewOpenAPI returns an empty OpenAPI registry.
+func NewOpenAPI(info Info) *OpenAPI {
+	return &OpenAPI{
+		mu:     sync.RWMutex{},
+		info:   info,
+		routes: nil,
+	}
+}
+
+// Add records the route of the handler, whose pattern follows the syntax of http.ServeMux and must have a method (eg. "GET /items/{pk}").
+// It replaces the route previously recorded with the same method and path, if any.
+func (o *OpenAPI) Add(pattern string, h http.Handler, opts ...DocOption) error {
+	method, path, ok := parsePattern(pattern)
+	if !ok {
+		return errors.Join(ErrInvalidPattern, errors.New("OpenAPI routes need a method and a path: "+pattern)) //nolint:err113
+	}
+
+	r := &route{
+		method: method,
+		path:   path,
+		types:  nil,
+		doc:    routeDoc{operationID: "", summary: "", description: "", tags: nil, deprecated: false, status: 0, errors: nil},
+	}
+
+	if typed, ok := h.(*typedHandler); ok {
+		r.types = &typed.types
+	}
+
+	for _, opt := range opts {
+		opt(&r.doc)
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.routes = slices.DeleteFunc(o.routes, func(other *route) bool {
+		return other.method == r.method && other.path == r.path
+	})
+	o.routes = append(o.routes, r)
+
+	return nil
+}
+
+// parsePattern returns the method and OpenAPI path of a pattern of http.ServeMux. The host, if any, is dropped.
+func parsePattern(pattern string) (string, string, bool) {
+	method, rest, ok := strings.Cut(strings.TrimSpace(pattern), " ")
+	if !ok || method == "" {
+		return "", "", false
+	}
+
+	rest = strings.TrimSpace(rest)
+
+	slash := strings.Index(rest, "/")
+	if slash < 0 {
+		return "", "", false
+	}
+
+	path := strings.TrimSuffix(rest[slash:], "{$}")
+	path = wildcard.ReplaceAllString(path, "{$1}")
+
+	return strings.ToLower(method), path, true
+}
+
+// Document generates the OpenAPI document of the recorded routes.
+func (o *OpenAPI) Document() *Document {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	gen := newSchemaGenerator()
+	doc := &Document{
+		OpenAPI:    OpenAPIVersion,
+		Info:       o.info,
+		Paths:      map[string]PathItem{},
+		Components: Components{Schemas: gen.schemas},
+	}
+
+	for _, r := range o.routes {
+		if doc.Paths[r.path] == nil {
+			doc.Paths[r.path] = PathItem{}
+		}
+
+		doc.Paths[r.path][r.method] = r.operation(gen)
+	}
+
+	return doc
+}
+
+// JSON returns the OpenAPI document encoded to JSON.
+func (o *OpenAPI) JSON() ([]byte, error) {
+	return json.MarshalIndent(o.Document(), "", "  ") //nolint:wrapcheck
+}
+
+// YAML returns the OpenAPI document encoded to YAML.
+func (o *OpenAPI) YAML() ([]byte, error) {
+	b, err := json.Marshal(o.Document())
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	return yaml.JSONToYAML(b) //nolint:wrapcheck
+}
+
+// ServeHTTP serves the OpenAPI document as JSON, or as YAML if the client accepts application/yaml or the path ends in .yaml.
+func (o *OpenAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var (
+		b           []byte
+		err         error
+		contentType = MediaTypeJSON
+	)
+
+	if strings.HasSuffix(r.URL.Path, ".yaml") || strings.Contains(r.Header.Get("Accept"), "yaml") {
+		contentType = "application/yaml"
+		b, err = o.YAML()
+	} else {
+		b, err = o.JSON()
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	_, _ = w.Write(b)
+}
+
+// operation describes the route.
+func (r *route) operation(gen *schemaGenerator) *Operation {
+	op := &Operation{
+		OperationID: r.doc.operationID,
+		Summary:     r.doc.summary,
+		Description: r.doc.description,
+		Tags:        r.doc.tags,
+		Deprecated:  r.doc.deprecated,
+		Parameters:  nil,
+		RequestBody: nil,
+		Responses:   map[string]*OperationResponse{},
+	}
+
+	documented := map[string]bool{}
+
+	if r.types != nil {
+		for _, param := range inParams(r.types.args) {
+			if param.source == sourceForm {
+				continue
+			}
+
+			p := &Parameter{
+				Name:     param.name,
+				In:       param.source,
+				Required: param.required || param.source == sourcePath,
+				Style:    "",
+				Explode:  nil,
+				Schema:   withRules(gen.schema(param.field.Type), param.rules),
+			}
+
+			if !param.explode {
+				p.Style, p.Explode = "form", ptr(false)
+			}
+
+			documented[param.source+":"+param.name] = true
+			op.Parameters = append(op.Parameters, p)
+		}
+
+		op.RequestBody = r.requestBody(gen)
+		r.responses(gen, op.Responses)
+	} else {
+		op.Responses["default"] = &OperationResponse{Description: "Response of an undocumented handler.", Content: nil}
+	}
+
+	// Wildcards of the pattern are path parameters, whether the handler reads them or not.
+	for _, match := range wildcard.FindAllStringSubmatch(r.path, -1) {
+		if !documented[sourcePath+":"+match[1]] {
+			op.Parameters = append(op.Parameters, &Parameter{
+				Name:     match[1],
+				In:       sourcePath,
+				Required: true,
+				Style:    "",
+				Explode:  nil,
+				Schema:   &Schema{Type: "string"}, //nolint:exhaustruct
+			})
+		}
+	}
+
+	return op
+}
+
+// requestBody describes the body the handler reads, if any.
+func (r *route) requestBody(gen *schemaGenerator) *RequestBody {
+	in := r.types.in
+	if in == nil || in == reflect.TypeFor[struct{}]() {
+		return nil
+	}
+
+	// Uploads are bound from multipart forms, and carry files besides the form values.
+	if reflect.PointerTo(in).Implements(reflect.TypeFor[bodyReader]()) {
+		form, _ := in.FieldByName("Form")
+
+		schema := gen.formSchema(form.Type)
+		schema.AdditionalProperties = &Schema{Type: "string", Format: "binary"} //nolint:exhaustruct
+
+		return &RequestBody{Required: true, Content: map[string]*Content{MediaTypeMultipart: {Schema: schema}}}
+	}
+
+	return &RequestBody{Required: true, Content: map[string]*Content{MediaTypeJSON: {Schema: gen.schema(in)}}}
+}
+
+// responses describes the successful and error responses of the handler.
+func (r *route) responses(gen *schemaGenerator, responses map[string]*OperationResponse) {
+	status := r.doc.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	success := &OperationResponse{Description: http.StatusText(status), Content: map[string]*Content{}}
+	responses[strconv.Itoa(status)] = success
+
+	// Responses choose their status and headers, the body being what's documented.
+	out := outputBodyType(r.types.out)
+
+	switch {
+	case r.types.stream != "":
+		// Events are documented by their data, their other fields being part of the stream's format.
+		if out.Kind() == reflect.Struct && out.Implements(reflect.TypeFor[eventer]()) {
+			data, _ := out.FieldByName("Data")
+			out = data.Type
+		}
+
+		success.Content[r.types.stream] = &Content{Schema: gen.schema(out)}
+	case out == reflect.TypeFor[*Download]():
+		success.Content["application/octet-stream"] = &Content{Schema: &Schema{Type: "string", Format: "binary"}} //nolint:exhaustruct
+		responses[strconv.Itoa(http.StatusPartialContent)] = &OperationResponse{
+			Description: http.StatusText(http.StatusPartialContent),
+			Content:     success.Content,
+		}
+	case out == reflect.TypeFor[struct{}]() || !bodyAllowed(status):
+		success.Content = nil
+	default:
+		schema := gen.schema(out)
+
+		for _, contentType := range r.types.cfg.encoders.contentTypes(out) {
+			success.Content[contentType] = &Content{Schema: schema}
+		}
+	}
+
+	r.errorResponses(gen, responses)
+}
+
+// errorResponses describes the error responses of the handler, as ErrResponse or problem details.
+func (r *route) errorResponses(gen *schemaGenerator, responses map[string]*OperationResponse) {
+	statuses := append(slices.Clone(r.doc.errors), http.StatusInternalServerError)
+
+	if len(inParams(r.types.args)) > 0 || r.requestBody(gen) != nil {
+		statuses = append(statuses, http.StatusBadRequest)
+	}
+
+	enc := r.types.cfg.encoders.Default()
+	contentType := enc.ContentType()
+
+	var schema *Schema
+
+	if r.types.cfg.problemDetails {
+		contentType = problemContentType(enc)
+		schema = problemSchema(gen)
+	} else {
+		schema = gen.schema(reflect.TypeFor[ErrResponse]())
+	}
+
+	for _, status := range statuses {
+		responses[strconv.Itoa(status)] = &OperationResponse{
+			Description: http.StatusText(status),
+			Content:     map[string]*Content{contentType: {Schema: schema}},
+		}
+	}
+}
+
+// problemSchema returns a reference to the schema of the problem details, as served by the ProblemDetails option.
+func problemSchema(gen *schemaGenerator) *Schema {
+	const name = "Problem"
+
+	if _, ok := gen.schemas[name]; !ok {
+		gen.schemas[name] = &Schema{ //nolint:exhaustruct
+			Type: "object",
+			Properties: map[string]*Schema{
+				"type":          {Type: "string", Format: "uri-reference"}, //nolint:exhaustruct
+				"title":         {Type: "string"},                          //nolint:exhaustruct
+				"status":        {Type: "integer", Format: "int32"},        //nolint:exhaustruct
+				"detail":        {Type: "string"},                          //nolint:exhaustruct
+				"instance":      {Type: "string", Format: "uri-reference"}, //nolint:exhaustruct
+				"correlationId": {Type: "string"},                          //nolint:exhaustruct
+				"errors":        gen.schema(reflect.TypeFor[FieldErrors]()),
+			},
+			AdditionalProperties: &Schema{}, //nolint:exhaustruct
+		}
+	}
+
+	return &Schema{Ref: "#/components/schemas/" + name} //nolint:exhaustruct
+}
+
+// contentTypes returns the content types the values of type t can be negotiated to.
+func (e *Encoders) contentTypes(t reflect.Type) []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	contentTypes := make([]string, 0, len(e.encoders))
+
+	// Encoders are asked about a non-nil value, as handlers seldom return nil pointers.
+	sample := reflect.Zero(t)
+	if t.Kind() == reflect.Ptr {
+		sample = reflect.New(t.Elem())
+	}
+
+	for _, enc := range e.encoders {
+		if checker, ok := enc.(canEncoder); ok && (t.Kind() == reflect.Interface || !checker.CanEncode(sample.Interface())) {
+			continue
+		}
+
+		contentTypes = append(contentTypes, enc.ContentType())
+	}
+
+	return slices.Sorted(slices.Values(contentTypes))
+}
@@ -0,0 +1,277 @@
+package handler
+
+import (
+	"encoding"
+	"encoding/json"
+	"net/url"
+	"path"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is a JSON Schema, as used by OpenAPI 3.1 documents.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	ContentEncoding      string             `json:"contentEncoding,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+}
+
+// packagePath matches the package paths of the type arguments in the name of generic types.
+var packagePath = regexp.MustCompile(`([\w-]+[./])+`) //nolint:gochecknoglobals
+
+// schemaGenerator generates the schemas of Go types. Named structs are stored as components, and referenced.
+type schemaGenerator struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{
+		schemas: map[string]*Schema{},
+		names:   map[reflect.Type]string{},
+	}
+}
+
+// schema returns the schema of the values of type t, once encoded to JSON.
+func (g *schemaGenerator) schema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case reflect.TypeFor[time.Time]():
+		return &Schema{Type: "string", Format: "date-time"} //nolint:exhaustruct
+	case reflect.TypeFor[url.URL]():
+		return &Schema{Type: "string", Format: "uri"} //nolint:exhaustruct
+	case reflect.TypeFor[json.Number]():
+		return &Schema{Type: "number"} //nolint:exhaustruct
+	}
+
+	// Types encoding themselves can be anything, but text is text.
+	switch {
+	case reflect.PointerTo(t).Implements(reflect.TypeFor[json.Marshaler]()):
+		return &Schema{} //nolint:exhaustruct
+	case reflect.PointerTo(t).Implements(reflect.TypeFor[encoding.TextMarshaler]()):
+		return &Schema{Type: "string"} //nolint:exhaustruct
+	}
+
+	switch t.Kind() { //nolint:exhaustive
+	case reflect.Bool:
+		return &Schema{Type: "boolean"} //nolint:exhaustruct
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"} //nolint:exhaustruct
+	case reflect.Int, reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"} //nolint:exhaustruct
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Minimum: ptr(0.0)} //nolint:exhaustruct
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"} //nolint:exhaustruct
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"} //nolint:exhaustruct
+	case reflect.String:
+		return &Schema{Type: "string"} //nolint:exhaustruct
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", ContentEncoding: "base64"} //nolint:exhaustruct
+		}
+
+		return &Schema{Type: "array", Items: g.schema(t.Elem())} //nolint:exhaustruct
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())} //nolint:exhaustruct
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+
+		return g.ref(t)
+	default:
+		return &Schema{} //nolint:exhaustruct
+	}
+}
+
+// ref stores the schema of a named struct as a component, and returns a reference to it.
+func (g *schemaGenerator) ref(t reflect.Type) *Schema {
+	name, ok := g.names[t]
+	if !ok {
+		name = g.name(t)
+		g.names[t] = name
+
+		// Store the schema before generating it, so that recursive types reference it.
+		s := &Schema{} //nolint:exhaustruct
+		g.schemas[name] = s
+		*s = *g.object(t)
+	}
+
+	return &Schema{Ref: "#/components/schemas/" + name} //nolint:exhaustruct
+}
+
+// name returns a unique component name for t. Package paths are stripped from the type arguments of generic types.
+func (g *schemaGenerator) name(t reflect.Type) string {
+	clean := func(s string) string {
+		return strings.Trim(componentName.ReplaceAllString(packagePath.ReplaceAllString(s, ""), "_"), "_")
+	}
+
+	name := clean(t.Name())
+
+	if _, taken := g.schemas[name]; taken {
+		name = clean(path.Base(t.PkgPath())) + "." + name
+	}
+
+	for i := 2; ; i++ {
+		if _, taken := g.schemas[name]; !taken {
+			return name
+		}
+
+		name = strings.TrimRight(name, "0123456789") + strconv.Itoa(i)
+	}
+}
+
+// componentName matches the characters that are not allowed in the names of components.
+var componentName = regexp.MustCompile(`[^A-Za-z0-9._-]+`) //nolint:gochecknoglobals
+
+// object returns the schema of a struct. Fields of embedded structs are promoted, as encoding/json does.
+func (g *schemaGenerator) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}} //nolint:exhaustruct
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+
+		if field.Anonymous && tag == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+
+			if embedded.Kind() == reflect.Struct {
+				promoted := g.object(embedded)
+
+				for name, prop := range promoted.Properties {
+					s.Properties[name] = prop
+				}
+
+				s.Required = append(s.Required, promoted.Required...)
+
+				continue
+			}
+		}
+
+		if !field.IsExported() || tag == "-" {
+			continue
+		}
+
+		name := jsonName(&field)
+		prop := g.schema(field.Type)
+
+		rules := parseRules(strings.Split(field.Tag.Get("validate"), ","))
+		for _, r := range rules {
+			if r.name == ruleRequired {
+				s.Required = append(s.Required, name)
+			}
+		}
+
+		s.Properties[name] = withRules(prop, rules)
+	}
+
+	return s
+}
+
+// withRules adds the keywords matching the validation rules to the schema.
+// As with Validate, the rules other than minlen and maxlen apply to the elements of arrays.
+func withRules(s *Schema, rules []rule) *Schema {
+	for _, r := range rules {
+		target := s
+		if s.Type == "array" && r.name != ruleMinLen && r.name != ruleMaxLen {
+			target = s.Items
+		}
+
+		switch r.name {
+		case ruleMin, ruleMax:
+			if limit, err := strconv.ParseFloat(r.param, 64); err == nil {
+				if r.name == ruleMin {
+					target.Minimum = &limit
+				} else {
+					target.Maximum = &limit
+				}
+			}
+		case ruleMinLen, ruleMaxLen:
+			if limit, err := strconv.Atoi(r.param); err == nil {
+				switch {
+				case target.Type == "array" && r.name == ruleMinLen:
+					target.MinItems = &limit
+				case target.Type == "array":
+					target.MaxItems = &limit
+				case r.name == ruleMinLen:
+					target.MinLength = &limit
+				default:
+					target.MaxLength = &limit
+				}
+			}
+		case ruleOneOf:
+			for _, v := range strings.Split(r.param, "|") {
+				if n, err := strconv.ParseInt(v, 10, 64); err == nil && target.Type == "integer" {
+					target.Enum = append(target.Enum, n)
+				} else {
+					target.Enum = append(target.Enum, v)
+				}
+			}
+		case rulePattern:
+			target.Pattern = r.param
+		}
+	}
+
+	return s
+}
+
+// formSchema returns the schema of the form values bound to a struct via the `in` struct tags.
+func (g *schemaGenerator) formSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}} //nolint:exhaustruct
+
+	for _, param := range inParams(t) {
+		if param.source != sourceForm && param.source != sourceQuery {
+			continue
+		}
+
+		s.Properties[param.name] = withRules(g.schema(param.field.Type), param.rules)
+
+		if param.required {
+			s.Required = append(s.Required, param.name)
+		}
+	}
+
+	return s
+}
+
+// inParams returns the fields of t that are bound via the `in` struct tag, from the plan InputFromRequest binds them with.
+func inParams(t reflect.Type) []fieldPlan {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	return planFor(t, sourceQuery).fields
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,167 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/luca-arch/go-goodies/handler"
+	"github.com/luca-arch/go-goodies/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Envelope is a type of the application that is not to be mistaken for handler.Response.
+type Envelope[T any] struct {
+	Data T `json:"data"`
+}
+
+// Response is named like handler.Response, but served as it is.
+type Response[T any] struct {
+	Envelope[T]
+}
+
+func newOpenAPI(t *testing.T) *handler.OpenAPI {
+	t.Helper()
+
+	api := handler.NewOpenAPI(handler.Info{Title: "Shop", Version: "1.0.0", Description: ""})
+
+	require.NoError(t, api.Add("POST /orders/{pk}", handler.New(func(_ context.Context, r *handler.Request[StructRules, Order]) (*handler.Response[Order], error) {
+		return handler.Created("/orders/1", r.In), nil
+	}), handler.Summary("Create an order"), handler.Tags("orders"), handler.SuccessStatus(http.StatusCreated), handler.ErrorStatus(http.StatusConflict)))
+
+	require.NoError(t, api.Add("POST /albums", handler.WithUpload(logger.NewNop(), func(_ context.Context, _ struct{}, _ *handler.Upload[Album]) ([]Photo, error) {
+		return nil, nil
+	}, handler.ProblemDetails())))
+
+	require.NoError(t, api.Add("GET /orders", handler.WithOutput(logger.NewNop(), func(_ context.Context) (*Response[[]Order], error) {
+		return nil, nil
+	})))
+
+	require.NoError(t, api.Add("GET /progress", handler.WithStream(logger.NewNop(), countdown)))
+	require.NoError(t, api.Add("GET /files/{path...}", http.NotFoundHandler()))
+
+	return api
+}
+
+func TestOpenAPI(t *testing.T) {
+	t.Parallel()
+
+	var doc handler.Document
+
+	w := httptest.NewRecorder()
+	newOpenAPI(t).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+
+	assert.Equal(t, "3.1.0", doc.OpenAPI)
+	assert.Equal(t, "Shop", doc.Info.Title)
+
+	t.Run("parameters", func(t *testing.T) {
+		t.Parallel()
+
+		op := doc.Paths["/orders/{pk}"]["post"]
+		require.NotNil(t, op)
+
+		params := map[string]*handler.Parameter{}
+		for _, p := range op.Parameters {
+			params[p.In+":"+p.Name] = p
+		}
+
+		assert.Equal(t, "Create an order", op.Summary)
+		assert.Equal(t, []string{"orders"}, op.Tags)
+		assert.True(t, params["path:pk"].Required)
+		assert.InDelta(t, 1.0, *params["path:pk"].Schema.Minimum, 0)
+		assert.InDelta(t, 100.0, *params["query:limit"].Schema.Maximum, 0)
+		assert.Equal(t, []any{"open", "closed"}, params["query:status"].Schema.Enum)
+		assert.Equal(t, "^[a-z]+$", params["query:code"].Schema.Pattern)
+		assert.Equal(t, "date-time", params["query:from"].Schema.Format)
+	})
+
+	t.Run("bodies", func(t *testing.T) {
+		t.Parallel()
+
+		op := doc.Paths["/orders/{pk}"]["post"]
+		require.NotNil(t, op)
+
+		assert.Equal(t, "#/components/schemas/Order", op.RequestBody.Content["application/json"].Schema.Ref)
+		assert.Equal(t, "#/components/schemas/Order", op.Responses["201"].Content["application/xml"].Schema.Ref)
+		assert.Equal(t, "#/components/schemas/ErrResponse", op.Responses["409"].Content["application/json"].Schema.Ref)
+		assert.ElementsMatch(t, []string{"201", "400", "409", "500"}, slices.Collect(maps.Keys(op.Responses)))
+
+		order := doc.Components.Schemas["Order"]
+		assert.Equal(t, []string{"customer"}, order.Required)
+		assert.Equal(t, []any{float64(1), float64(2), float64(3)}, order.Properties["priority"].Enum)
+		assert.Equal(t, 1, *order.Properties["items"].MinItems)
+		assert.Equal(t, "#/components/schemas/Item", order.Properties["items"].Items.Ref)
+		assert.Equal(t, 2, *doc.Components.Schemas["Item"].Properties["name"].MinLength)
+	})
+
+	t.Run("application response", func(t *testing.T) {
+		t.Parallel()
+
+		op := doc.Paths["/orders"]["get"]
+		require.NotNil(t, op)
+
+		schema := op.Responses["200"].Content["application/json"].Schema
+		require.NotEmpty(t, schema.Ref)
+		assert.Contains(t, doc.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")].Properties, "data")
+	})
+
+	t.Run("upload", func(t *testing.T) {
+		t.Parallel()
+
+		op := doc.Paths["/albums"]["post"]
+		require.NotNil(t, op)
+
+		form := op.RequestBody.Content["multipart/form-data"].Schema
+		assert.Equal(t, []string{"title"}, form.Required)
+		assert.Equal(t, "binary", form.AdditionalProperties.Format)
+		assert.Equal(t, "array", op.Responses["200"].Content["application/json"].Schema.Type)
+		assert.Equal(t, "#/components/schemas/Problem", op.Responses["400"].Content["application/problem+json"].Schema.Ref)
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		t.Parallel()
+
+		op := doc.Paths["/progress"]["get"]
+		require.NotNil(t, op)
+
+		assert.Equal(t, "#/components/schemas/Progress", op.Responses["200"].Content["text/event-stream"].Schema.Ref)
+	})
+
+	t.Run("undocumented handler", func(t *testing.T) {
+		t.Parallel()
+
+		op := doc.Paths["/files/{path}"]["get"]
+		require.NotNil(t, op)
+
+		assert.Equal(t, "path", op.Parameters[0].Name)
+		assert.Contains(t, op.Responses, "default")
+	})
+}
+
+func TestOpenAPIYAML(t *testing.T) {
+	t.Parallel()
+
+	w := httptest.NewRecorder()
+	newOpenAPI(t).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.yaml", nil))
+
+	assert.Equal(t, "application/yaml", w.Header().Get("Content-Type"))
+	assert.True(t, strings.HasPrefix(w.Body.String(), "openapi: 3.1.0\n"))
+	assert.Contains(t, w.Body.String(), "  /orders/{pk}:\n    post:\n")
+}
+
+func TestOpenAPIInvalidPattern(t *testing.T) {
+	t.Parallel()
+
+	api := handler.NewOpenAPI(handler.Info{Title: "Shop", Version: "1.0.0", Description: ""})
+
+	require.ErrorIs(t, api.Add("/orders", http.NotFoundHandler()), handler.ErrInvalidPattern)
+}
@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/luca-arch/go-goodies/logger"
+)
+
+// defaultFlushInterval is how often NDJSON streams are flushed, unless set via FlushInterval.
+const defaultFlushInterval = 100 * time.Millisecond
+
+// Option customises the HTTP handlers created by New and the With* helpers.
+type Option func(*config)
+
+// config holds the settings of an HTTP handler.
+type config struct {
+	decoder        BodyDecoder
+	encoders       *Encoders
+	flushInterval  time.Duration
+	heartbeat      time.Duration
+	json           JSONDecoder
+	logger         *slog.Logger
+	mapper         *ErrorMapper
+	maxBodyBytes   int64
+	maxFileBytes   int64
+	maxMemory      int64
+	middlewares    []Middleware
+	problemDetails bool
+	safeErrors     bool
+	validators     []func(any) error
+}
+
+var (
+	defaultOptions   []Option     //nolint:gochecknoglobals
+	defaultOptionsMu sync.RWMutex //nolint:gochecknoglobals
+)
+
+// SetDefaults replaces the options applied to all the handlers created afterwards, before their own options.
+// Handlers are configured when they are created: the ones created earlier (eg. package-level variables) keep their settings,
+// so it is meant to be called at startup, before any handler is built.
+func SetDefaults(opts ...Option) {
+	defaultOptionsMu.Lock()
+	defer defaultOptionsMu.Unlock()
+
+	defaultOptions = opts
+}
+
+// newConfig returns the settings resulting from the given options.
+func newConfig(opts []Option) *config {
+	cfg := &config{
+		decoder:        DefaultDecoders,
+		encoders:       DefaultEncoders,
+		flushInterval:  defaultFlushInterval,
+		heartbeat:      0,
+		json:           JSONDecoder{DisallowUnknownFields: false, RejectTrailingData: false, UseNumber: false},
+		logger:         logger.NewNop(),
+		mapper:         DefaultErrorMapper,
+		maxBodyBytes:   0,
+		maxFileBytes:   0,
+		maxMemory:      defaultMaxMemory,
+		middlewares:    nil,
+		problemDetails: false,
+		safeErrors:     false,
+		validators:     nil,
+	}
+
+	defaultOptionsMu.RLock()
+	opts = append(slices.Clone(defaultOptions), opts...)
+	defaultOptionsMu.RUnlock()
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	// The JSON options apply to the JSON decoder of the registry, or to the JSON decoder itself.
+	if cfg.json != (JSONDecoder{}) {
+		switch dec := cfg.decoder.(type) {
+		case *Decoders:
+			cfg.decoder = dec.withJSON(cfg.json)
+		case JSONDecoder:
+			cfg.decoder = JSONDecoder{
+				DisallowUnknownFields: dec.DisallowUnknownFields || cfg.json.DisallowUnknownFields,
+				RejectTrailingData:    dec.RejectTrailingData || cfg.json.RejectTrailingData,
+				UseNumber:             dec.UseNumber || cfg.json.UseNumber,
+			}
+		default:
+			panic(fmt.Sprintf("handler: the JSON options need a JSONDecoder or Decoders, not %T", dec))
+		}
+	}
+
+	return cfg
+}
+
+// Decoder sets the BodyDecoder of the request's body. It defaults to DefaultDecoders, which picks one according to the Content-Type.
+// The JSON options (eg. DisallowUnknownFields) apply to a JSONDecoder or Decoders, and make New panic with any other decoder.
+func Decoder(d BodyDecoder) Option {
+	return func(c *config) {
+		c.decoder = d
+	}
+}
+
+// DisallowUnknownFields rejects JSON bodies with fields that don't exist in the handler's input.
+func DisallowUnknownFields() Option {
+	return func(c *config) {
+		c.json.DisallowUnknownFields = true
+	}
+}
+
+// Encoder restricts the formats the response's body is negotiated among, the first encoder being the default.
+// It defaults to DefaultEncoders.
+func Encoder(encs ...ResponseEncoder) Option {
+	return func(c *config) {
+		c.encoders = NewEncoders(encs...)
+	}
+}
+
+// ErrorMapping makes the handler consult the given ErrorMapper, instead of the DefaultErrorMapper.
+func ErrorMapping(m *ErrorMapper) Option {
+	return func(c *config) {
+		c.mapper = m
+	}
+}
+
+// FlushInterval sets how often NDJSON streams are flushed to the client. It defaults to 100ms.
+// Records are written as they come, but flushing them in batches is cheaper. Zero flushes every record.
+func FlushInterval(interval time.Duration) Option {
+	return func(c *config) {
+		c.flushInterval = interval
+	}
+}
+
+// Heartbeat makes streaming handlers write every interval, so that proxies don't close idle connections.
+// It only applies to Server-Sent Events, which get an empty comment. Zero, the default, disables heartbeats.
+func Heartbeat(interval time.Duration) Option {
+	return func(c *config) {
+		c.heartbeat = interval
+	}
+}
+
+// Logger sets the logger of the handler. A nil logger is ignored.
+func Logger(l *slog.Logger) Option {
+	return func(c *config) {
+		if l != nil {
+			c.logger = l
+		}
+	}
+}
+
+// MaxBodyBytes limits the size of the request's body. Larger bodies are rejected with 413.
+// Zero means no limit, except for the handlers taking an Upload, which default to 32 MiB.
+func MaxBodyBytes(n int64) Option {
+	return func(c *config) {
+		c.maxBodyBytes = n
+	}
+}
+
+// MaxFileBytes limits the size of each file uploaded to the handlers taking an Upload. Larger files are rejected with 413.
+// Zero means no limit, although MaxBodyBytes still limits the size of all the files together.
+func MaxFileBytes(n int64) Option {
+	return func(c *config) {
+		c.maxFileBytes = n
+	}
+}
+
+// MaxMemory sets how many bytes of the files uploaded to the handlers taking an Upload are kept in memory.
+// The rest is stored in temporary files, which are removed once the response has been served. It defaults to 32MB.
+func MaxMemory(n int64) Option {
+	return func(c *config) {
+		c.maxMemory = n
+	}
+}
+
+// ProblemDetails makes the handler serve errors as RFC 9457 problem details (application/problem+json) rather than ErrResponse.
+func ProblemDetails() Option {
+	return func(c *config) {
+		c.problemDetails = true
+	}
+}
+
+// RejectTrailingData rejects JSON bodies with data following the first JSON value.
+func RejectTrailingData() Option {
+	return func(c *config) {
+		c.json.RejectTrailingData = true
+	}
+}
+
+// SafeErrors prevents internal error messages from reaching clients: 5xx responses get a generic message and a correlation ID,
+// while the full error is logged along with the same ID. Errors marked with Public are still served as is.
+func SafeErrors() Option {
+	return func(c *config) {
+		c.safeErrors = true
+	}
+}
+
+// Use wraps the handler with the given middlewares (eg. the ones of the middleware package). The first one is the outermost.
+func Use(mws ...Middleware) Option {
+	return func(c *config) {
+		c.middlewares = append(c.middlewares, mws...)
+	}
+}
+
+// UseNumber decodes JSON numbers into json.Number rather than float64, when the input has no specific type for them.
+func UseNumber() Option {
+	return func(c *config) {
+		c.json.UseNumber = true
+	}
+}
+
+// Validators adds validation functions, which are called with the decoded Args and In once their tag rules are satisfied.
+// Their errors are served with 400.
+func Validators(fns ...func(any) error) Option {
+	return func(c *config) {
+		c.validators = append(c.validators, fns...)
+	}
+}
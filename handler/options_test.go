@@ -0,0 +1,51 @@
+package handler_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/luca-arch/go-goodies/handler"
+	"github.com/stretchr/testify/assert"
+)
+
+//nolint:paralleltest // Changes the defaults of the handlers, so it must not run along with other tests.
+func TestSetDefaults(t *testing.T) {
+	t.Cleanup(func() { handler.SetDefaults() })
+
+	f := func(_ context.Context, _ *handler.Request[struct{}, struct{}]) (any, error) {
+		return nil, handler.NotFound(errors.New("no such item"))
+	}
+
+	before := handler.New(f)
+
+	handler.SetDefaults(handler.ProblemDetails())
+
+	after := handler.New(f)
+
+	tests := map[string]struct {
+		handler     http.Handler
+		contentType string
+	}{
+		"created before": {
+			handler:     before,
+			contentType: "application/json",
+		},
+		"created after": {
+			handler:     after,
+			contentType: "application/problem+json",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			test.handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, http.StatusNotFound, w.Code)
+			assert.Equal(t, test.contentType, w.Header().Get("Content-Type"))
+		})
+	}
+}
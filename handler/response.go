@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"net/http"
+	"reflect"
+)
+
+// Bodier is implemented by outputs that serve something else than themselves as the response's body.
+type Bodier interface {
+	ResponseBody() any
+}
+
+// typedBodier is implemented by Response, to tell the type of its body without a value.
+type typedBodier interface {
+	bodyType() reflect.Type
+}
+
+// outputBodyType returns the type of the body served for outputs of type t, which is T for a *Response[T].
+func outputBodyType(t reflect.Type) reflect.Type {
+	if t.Implements(reflect.TypeFor[typedBodier]()) {
+		return reflect.Zero(t).Interface().(typedBodier).bodyType() //nolint:forcetypeassert
+	}
+
+	return t
+}
+
+// Response wraps the output of a handler function, to choose the HTTP status and headers it is served with.
+// Any output implementing StatusCoder, Headerer or Bodier is treated the same way.
+type Response[T any] struct {
+	Status  int
+	Headers http.Header
+	Body    T
+}
+
+// NewResponse returns a response served with the given status.
+func NewResponse[T any](status int, body T) *Response[T] {
+	return &Response[T]{Status: status, Headers: http.Header{}, Body: body}
+}
+
+// Accepted returns a response served with 202 Accepted.
+func Accepted[T any](body T) *Response[T] {
+	return NewResponse(http.StatusAccepted, body)
+}
+
+// Created returns a response served with 201 Created, and the location of the new resource.
+func Created[T any](location string, body T) *Response[T] {
+	return NewResponse(http.StatusCreated, body).SetHeader("Location", location)
+}
+
+// NoContent returns a response served with 204 No Content and an empty body.
+func NoContent() *Response[struct{}] {
+	return NewResponse(http.StatusNoContent, struct{}{})
+}
+
+// SetHeader sets a header of the response.
+func (r *Response[T]) SetHeader(key, value string) *Response[T] {
+	if r.Headers == nil {
+		r.Headers = http.Header{}
+	}
+
+	r.Headers.Set(key, value)
+
+	return r
+}
+
+func (r *Response[T]) Header() http.Header {
+	if r == nil {
+		return nil
+	}
+
+	return r.Headers
+}
+
+func (r *Response[T]) ResponseBody() any {
+	if r == nil {
+		return nil
+	}
+
+	return r.Body
+}
+
+func (*Response[T]) bodyType() reflect.Type {
+	return reflect.TypeFor[T]()
+}
+
+func (r *Response[T]) StatusCode() int {
+	if r == nil || r.Status == 0 {
+		return http.StatusOK
+	}
+
+	return r.Status
+}
+
+// bodyAllowed reports whether a response with the given status can have a body.
+func bodyAllowed(status int) bool {
+	return status != http.StatusNoContent && status != http.StatusNotModified && status >= http.StatusOK
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+var ErrPathMismatch = errors.New("path parameters don't match the pattern")
+
+// Router registers HTTP handlers on an http.ServeMux, checking that the typed ones read the wildcards of their pattern.
+// Groups share the ServeMux of their router, and add a prefix and middlewares to their routes.
+// Routes are documented on the OpenAPI registry of the router, if any.
+type Router struct {
+	mux         *http.ServeMux
+	api         *OpenAPI
+	prefix      string
+	middlewares []Middleware
+}
+
+// NewRouter returns a router on a new http.ServeMux. Routes are documented on api, unless it is nil.
+func NewRouter(api *OpenAPI) *Router {
+	return &Router{
+		mux:         http.NewServeMux(),
+		api:         api,
+		prefix:      "",
+		middlewares: nil,
+	}
+}
+
+// Group returns a router whose routes have the given prefix (eg. "/v1"), and are wrapped with the middlewares of this router
+// and the given ones. The first middleware is the outermost.
+func (rt *Router) Group(prefix string, mws ...Middleware) *Router {
+	return &Router{
+		mux:         rt.mux,
+		api:         rt.api,
+		prefix:      rt.prefix + strings.TrimSuffix(prefix, "/"),
+		middlewares: append(slices.Clone(rt.middlewares), mws...),
+	}
+}
+
+// Use wraps the routes registered afterwards with the given middlewares.
+func (rt *Router) Use(mws ...Middleware) {
+	rt.middlewares = append(rt.middlewares, mws...)
+}
+
+// Handle registers the handler for the pattern, which follows the syntax of http.ServeMux (eg. "GET /items/{pk}").
+// For handlers created by New and the With* helpers, every `in:"name,path"` tag of Args must match a wildcard of the pattern,
+// and every wildcard of the pattern (but the ones of the group's prefix) must be read by Args: ErrPathMismatch is returned otherwise.
+// Like http.ServeMux, it panics if the pattern is invalid or conflicts with another one.
+func (rt *Router) Handle(pattern string, h http.Handler, opts ...DocOption) error {
+	method, host, path := splitPattern(pattern)
+	full := method + host + rt.prefix + path
+
+	if typed, ok := h.(*typedHandler); ok {
+		if err := checkPath(full, path, &typed.types); err != nil {
+			return err
+		}
+	}
+
+	// Routes are documented once registered, so that the document doesn't list the ones the mux panicked on.
+	rt.mux.Handle(full, wrap(h, rt.middlewares))
+
+	if rt.api != nil && method != "" {
+		return rt.api.Add(full, h, opts...)
+	}
+
+	return nil
+}
+
+// MustHandle is like Handle, but panics if the path parameters of the handler don't match the pattern.
+func (rt *Router) MustHandle(pattern string, h http.Handler, opts ...DocOption) {
+	if err := rt.Handle(pattern, h, opts...); err != nil {
+		panic(err)
+	}
+}
+
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt.mux.ServeHTTP(w, r)
+}
+
+// splitPattern splits a pattern of http.ServeMux into its method (along with the space that follows), host and path.
+func splitPattern(pattern string) (string, string, string) {
+	var method string
+
+	if before, after, ok := strings.Cut(pattern, " "); ok {
+		method, pattern = before+" ", strings.TrimLeft(after, " ")
+	}
+
+	slash := strings.Index(pattern, "/")
+	if slash < 0 {
+		return method, pattern, ""
+	}
+
+	return method, pattern[:slash], pattern[slash:]
+}
+
+// checkPath checks that the path parameters of the handler match the wildcards of the pattern.
+// Wildcards must be read by the handler only if they are part of the route's own path.
+func checkPath(pattern, path string, types *handlerTypes) error {
+	wildcards := map[string]bool{}
+	for _, match := range wildcard.FindAllStringSubmatch(pattern, -1) {
+		wildcards[match[1]] = true
+	}
+
+	read := map[string]bool{}
+
+	for _, param := range inParams(types.args) {
+		if param.source != sourcePath {
+			continue
+		}
+
+		if !wildcards[param.name] {
+			return errors.Join(ErrPathMismatch, errors.New("no wildcard for path parameter "+param.name+" in "+pattern)) //nolint:err113
+		}
+
+		read[param.name] = true
+	}
+
+	for _, match := range wildcard.FindAllStringSubmatch(path, -1) {
+		if !read[match[1]] {
+			return errors.Join(ErrPathMismatch, errors.New("wildcard "+match[1]+" of "+pattern+" is not read by any path parameter")) //nolint:err113
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package handler_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/luca-arch/go-goodies/handler"
+	"github.com/luca-arch/go-goodies/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type TenantArgs struct {
+	Tenant string `in:"tenant,path"`
+	PK     int    `in:"pk,path"`
+}
+
+func TestRouter(t *testing.T) {
+	t.Parallel()
+
+	api := handler.NewOpenAPI(handler.Info{Title: "Shop", Version: "1.0.0", Description: ""})
+	router := handler.NewRouter(api)
+
+	tag := func(name string) handler.Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("X-Middleware", name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	get := handler.WithArgsOutput(logger.NewNop(), func(_ context.Context, args TenantArgs) (TenantArgs, error) {
+		return args, nil
+	})
+
+	router.Use(tag("root"))
+
+	tenants := router.Group("/tenants/{tenant}/", tag("tenants"))
+	tenants.MustHandle("GET /items/{pk}", get, handler.Summary("Get an item"))
+
+	router.MustHandle("GET /health", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := map[string]struct {
+		target      string
+		status      int
+		middlewares []string
+		want        string
+	}{
+		"group": {
+			target:      "/tenants/acme/items/7",
+			status:      http.StatusOK,
+			middlewares: []string{"root", "tenants"},
+			want:        `{"Tenant":"acme","PK":7}` + "\n",
+		},
+		"root": {
+			target:      "/health",
+			status:      http.StatusNoContent,
+			middlewares: []string{"root"},
+			want:        "",
+		},
+		"not found": {
+			target:      "/items/7",
+			status:      http.StatusNotFound,
+			middlewares: nil,
+			want:        "404 page not found\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.target, nil))
+
+			assert.Equal(t, test.status, w.Code)
+			assert.Equal(t, test.middlewares, w.Header().Values("X-Middleware"))
+			assert.Equal(t, test.want, w.Body.String())
+		})
+	}
+
+	t.Run("documented", func(t *testing.T) {
+		t.Parallel()
+
+		doc := api.Document()
+
+		require.Contains(t, doc.Paths, "/tenants/{tenant}/items/{pk}")
+		assert.Equal(t, "Get an item", doc.Paths["/tenants/{tenant}/items/{pk}"]["get"].Summary)
+		assert.Contains(t, doc.Paths, "/health")
+	})
+
+	t.Run("conflicting route", func(t *testing.T) {
+		t.Parallel()
+
+		api := handler.NewOpenAPI(handler.Info{Title: "Shop", Version: "1.0.0", Description: ""})
+		router := handler.NewRouter(api)
+
+		router.MustHandle("GET /items/{pk}", http.NotFoundHandler())
+
+		assert.Panics(t, func() {
+			router.MustHandle("GET /items/{id}", http.NotFoundHandler())
+		})
+
+		assert.NotContains(t, api.Document().Paths, "/items/{id}")
+	})
+}
+
+func TestRouterPathMismatch(t *testing.T) {
+	t.Parallel()
+
+	h := handler.WithArgsOutput(logger.NewNop(), func(_ context.Context, args TenantArgs) (TenantArgs, error) {
+		return args, nil
+	})
+
+	tests := map[string]struct {
+		prefix  string
+		pattern string
+		wantErr string
+	}{
+		"missing wildcard": {
+			prefix:  "",
+			pattern: "GET /items/{pk}",
+			wantErr: "no wildcard for path parameter tenant in GET /items/{pk}",
+		},
+		"unread wildcard": {
+			prefix:  "/tenants/{tenant}",
+			pattern: "GET /items/{pk}/{version}",
+			wantErr: "wildcard version of GET /tenants/{tenant}/items/{pk}/{version} is not read by any path parameter",
+		},
+		"wildcard of the prefix": {
+			prefix:  "/tenants/{tenant}/users/{user}",
+			pattern: "GET /items/{pk...}",
+			wantErr: "",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := handler.NewRouter(nil).Group(test.prefix).Handle(test.pattern, h)
+
+			if test.wantErr == "" {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.ErrorIs(t, err, handler.ErrPathMismatch)
+			assert.ErrorContains(t, err, test.wantErr)
+			assert.Panics(t, func() { handler.NewRouter(nil).Group(test.prefix).MustHandle(test.pattern, h) })
+		})
+	}
+}
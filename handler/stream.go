@@ -0,0 +1,358 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"iter"
+	"net/http"
+	"reflect"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/luca-arch/go-goodies/handler/middleware"
+)
+
+// MediaTypeEventStream is the media type of Server-Sent Events.
+const MediaTypeEventStream = "text/event-stream"
+
+// singleLine strips line breaks from the fields of the events that cannot span multiple lines.
+var singleLine = strings.NewReplacer("\n", "", "\r", "") //nolint:gochecknoglobals
+
+// Event is an output of a stream along with the fields of its Server-Sent Event.
+// ID is sent back by reconnecting clients in the Last-Event-ID header, Name sets the event type and Retry the reconnection delay.
+type Event[T any] struct {
+	ID    string
+	Name  string
+	Retry time.Duration
+	Data  T
+}
+
+func (e Event[T]) event() (string, string, time.Duration, any) {
+	return e.ID, e.Name, e.Retry, e.Data
+}
+
+// eventer is implemented by Event, whatever the type of its data.
+type eventer interface {
+	event() (id, name string, retry time.Duration, data any)
+}
+
+// FromChan adapts a channel to the sequences streamed by WithStream and WithNDJSON.
+// The sequence ends when the channel is closed or the context is done.
+func FromChan[T any](ctx context.Context, ch <-chan T) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-ch:
+				if !ok || !yield(v, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// streamItem is an output of a stream, or the error that ended it.
+type streamItem[T any] struct {
+	out T
+	err error
+}
+
+// streamHandler creates an HTTP handler that reads the request's querystring, and streams the outputs of f in the given format.
+func streamHandler[Args any, Out any](cfg *config, f FuncWithStream[Args, Out], format streamFormat, flushInterval time.Duration) http.Handler {
+	mustCheckRules(reflect.TypeFor[Args]())
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r, cfg := withRequestLogger(r, cfg)
+
+		cfg.logger.Debug("HTTP request", "http.url", r.URL)
+
+		// Errors are served in the preferred format, falling back to the default one for clients that only accept the stream.
+		enc, ok := cfg.encoders.Negotiate(r.Header.Get("Accept"))
+		if !ok {
+			enc = cfg.encoders.Default()
+		}
+
+		// The writer records whether the response was started, in case of panics.
+		rw := middleware.NewResponseWriter(w)
+		w = rw
+
+		defer recoverPanic(rw, r, cfg, enc)
+
+		args, err := readArgs[Args](r, cfg)
+		if err != nil {
+			writeInputError(w, r, cfg, enc, err)
+
+			return
+		}
+
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+
+		serveStream(ctx, w, r, cfg, enc, format, flushInterval, f(ctx, args))
+	})
+
+	return &typedHandler{
+		Handler: wrap(h, cfg.middlewares),
+		types: handlerTypes{
+			args:   reflect.TypeFor[Args](),
+			in:     reflect.TypeFor[struct{}](),
+			out:    reflect.TypeFor[Out](),
+			stream: format.contentType(),
+			cfg:    cfg,
+		},
+	}
+}
+
+// streamFormat writes the records of a streamed response.
+type streamFormat interface {
+	contentType() string
+	writeRecord(w io.Writer, v any) error
+	writeError(w io.Writer, body any) error
+}
+
+// heartbeater is implemented by the stream formats that can write something clients ignore, to keep connections open.
+type heartbeater interface {
+	writeHeartbeat(w io.Writer) error
+}
+
+// serveStream writes each output of seq as a record, until the sequence is over or the context is done.
+// Errors yielded before the first output are served as regular error responses, later ones as a final error record.
+// Records are flushed every flushInterval, or as soon as they are written if it is zero.
+func serveStream[T any](
+	ctx context.Context,
+	w http.ResponseWriter,
+	r *http.Request,
+	cfg *config,
+	enc ResponseEncoder,
+	format streamFormat,
+	flushInterval time.Duration,
+	seq iter.Seq2[T, error],
+) {
+	var (
+		flush     <-chan time.Time
+		heartbeat <-chan time.Time
+		pending   bool
+		started   bool
+	)
+
+	rc := http.NewResponseController(w)
+	items := pull(ctx, r, cfg, seq)
+
+	hb, canHeartbeat := format.(heartbeater)
+
+	if cfg.heartbeat > 0 && canHeartbeat {
+		ticker := time.NewTicker(cfg.heartbeat)
+		defer ticker.Stop()
+
+		heartbeat = ticker.C
+	}
+
+	if flushInterval > 0 {
+		ticker := time.NewTicker(flushInterval)
+		defer ticker.Stop()
+
+		flush = ticker.C
+	}
+
+	// start writes the headers of the stream, once.
+	start := func() {
+		if started {
+			return
+		}
+
+		started = true
+
+		w.Header().Set("Content-Type", format.contentType())
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+	}
+
+	for {
+		var (
+			err  error
+			last bool
+			tick bool
+		)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-flush:
+			tick = true
+		case <-heartbeat:
+			start()
+
+			err = hb.writeHeartbeat(w)
+			pending = true
+		case item, ok := <-items:
+			switch {
+			case !ok:
+				start()
+
+				last = true
+			case item.err != nil && !started:
+				writeResponse[any](w, r, cfg, enc, nil, item.err)
+
+				return
+			case item.err != nil:
+				status, exposed := exposeError(r, cfg, item.err)
+
+				err = format.writeError(w, errorBody(r, cfg, exposed, status))
+				last = true
+			default:
+				start()
+
+				err = format.writeRecord(w, item.out)
+			}
+
+			pending = true
+		}
+
+		if err != nil {
+			cfg.logger.Warn("failed to write HTTP stream", "error", err)
+
+			return
+		}
+
+		// Writers that cannot flush still get the records, although buffered.
+		if pending && (flush == nil || tick || last) {
+			_ = rc.Flush()
+			pending = false
+		}
+
+		if last {
+			return
+		}
+	}
+}
+
+// pull iterates over seq in a goroutine, which stops when the context is done.
+// Panics of the iterator are logged and turned into ErrPanic.
+func pull[T any](ctx context.Context, r *http.Request, cfg *config, seq iter.Seq2[T, error]) <-chan streamItem[T] {
+	items := make(chan streamItem[T])
+
+	send := func(item streamItem[T]) bool {
+		select {
+		case items <- item:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	go func() {
+		defer close(items)
+
+		defer func() {
+			if v := recover(); v != nil {
+				cfg.logger.Error("HTTP stream panicked",
+					"panic", v,
+					"stack", string(debug.Stack()),
+					"http.url", r.URL,
+				)
+
+				send(streamItem[T]{out: *new(T), err: ErrPanic})
+			}
+		}()
+
+		for out, err := range seq {
+			if !send(streamItem[T]{out: out, err: err}) || err != nil {
+				return
+			}
+		}
+	}()
+
+	return items
+}
+
+// sseFormat writes Server-Sent Events.
+type sseFormat struct{}
+
+func (sseFormat) contentType() string {
+	return MediaTypeEventStream
+}
+
+// writeRecord writes v as an event. Strings are sent as they are, any other data is encoded to JSON.
+func (sseFormat) writeRecord(w io.Writer, v any) error {
+	var (
+		b     strings.Builder
+		id    string
+		name  string
+		retry time.Duration
+	)
+
+	if e, ok := v.(eventer); ok {
+		id, name, retry, v = e.event()
+	}
+
+	if id != "" {
+		b.WriteString("id: " + singleLine.Replace(id) + "\n")
+	}
+
+	if name != "" {
+		b.WriteString("event: " + singleLine.Replace(name) + "\n")
+	}
+
+	if retry > 0 {
+		b.WriteString("retry: " + strconv.FormatInt(retry.Milliseconds(), 10) + "\n")
+	}
+
+	data, ok := v.(string)
+	if !ok {
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+
+		data = string(encoded)
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+
+	b.WriteString("\n")
+
+	_, err := io.WriteString(w, b.String())
+
+	return err //nolint:wrapcheck
+}
+
+// writeError writes the error as an event named "error".
+func (f sseFormat) writeError(w io.Writer, body any) error {
+	return f.writeRecord(w, Event[any]{ID: "", Name: "error", Retry: 0, Data: body})
+}
+
+// writeHeartbeat writes an empty comment, which clients ignore.
+func (sseFormat) writeHeartbeat(w io.Writer) error {
+	_, err := io.WriteString(w, ":\n\n")
+
+	return err //nolint:wrapcheck
+}
+
+// ndjsonFormat writes newline-delimited JSON.
+type ndjsonFormat struct{}
+
+func (ndjsonFormat) contentType() string {
+	return MediaTypeNDJSON
+}
+
+// writeRecord writes v as a JSON line. The data of an Event is written without its other fields.
+func (ndjsonFormat) writeRecord(w io.Writer, v any) error {
+	if e, ok := v.(eventer); ok {
+		_, _, _, v = e.event()
+	}
+
+	return json.NewEncoder(w).Encode(v) //nolint:wrapcheck
+}
+
+// writeError writes the error as the final JSON line, wrapped in an object whose only member is NDJSONErrorMember.
+func (f ndjsonFormat) writeError(w io.Writer, body any) error {
+	return f.writeRecord(w, map[string]any{NDJSONErrorMember: body})
+}
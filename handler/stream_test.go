@@ -0,0 +1,200 @@
+package handler_test
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/luca-arch/go-goodies/handler"
+	"github.com/luca-arch/go-goodies/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+type StreamArgs struct {
+	LastEventID int `in:"Last-Event-ID,header"`
+	Fail        int `in:"fail"`
+}
+
+type Progress struct {
+	Done int `json:"done"`
+}
+
+func countdown(_ context.Context, args StreamArgs) iter.Seq2[handler.Event[Progress], error] {
+	return func(yield func(handler.Event[Progress], error) bool) {
+		for i := args.LastEventID + 1; i <= 3; i++ {
+			if i == args.Fail {
+				yield(handler.Event[Progress]{}, handler.Conflict(errors.New("step failed")))
+
+				return
+			}
+
+			if !yield(handler.Event[Progress]{ID: strconv.Itoa(i), Name: "progress", Retry: 0, Data: Progress{Done: i}}, nil) {
+				return
+			}
+		}
+	}
+}
+
+func TestWithStream(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		header      http.Header
+		target      string
+		status      int
+		contentType string
+		want        string
+	}{
+		"events": {
+			header:      http.Header{"Accept": {"text/event-stream"}},
+			target:      "/",
+			status:      http.StatusOK,
+			contentType: "text/event-stream",
+			want: "id: 1\nevent: progress\ndata: {\"done\":1}\n\n" +
+				"id: 2\nevent: progress\ndata: {\"done\":2}\n\n" +
+				"id: 3\nevent: progress\ndata: {\"done\":3}\n\n",
+		},
+		"resume": {
+			header:      http.Header{"Accept": {"text/event-stream"}, "Last-Event-Id": {"2"}},
+			target:      "/",
+			status:      http.StatusOK,
+			contentType: "text/event-stream",
+			want:        "id: 3\nevent: progress\ndata: {\"done\":3}\n\n",
+		},
+		"error event": {
+			header:      http.Header{"Accept": {"text/event-stream"}},
+			target:      "/?fail=2",
+			status:      http.StatusOK,
+			contentType: "text/event-stream",
+			want: "id: 1\nevent: progress\ndata: {\"done\":1}\n\n" +
+				"event: error\ndata: {\"error\":\"step failed\"}\n\n",
+		},
+		"error before the first event": {
+			header:      http.Header{"Accept": {"text/event-stream"}},
+			target:      "/?fail=1",
+			status:      http.StatusConflict,
+			contentType: "application/json",
+			want:        `{"error":"step failed"}` + "\n",
+		},
+		"invalid args": {
+			header:      http.Header{"Accept": {"text/event-stream"}, "Last-Event-Id": {"x"}},
+			target:      "/",
+			status:      http.StatusBadRequest,
+			contentType: "application/json",
+			want:        `{"error":"invalid input\ninvalid number for field: Last-Event-ID","fields":[{"field":"Last-Event-ID","source":"header","code":"invalid","message":"invalid number for field: Last-Event-ID"}]}` + "\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, test.target, nil)
+			r.Header = test.header
+
+			w := httptest.NewRecorder()
+			handler.WithStream(logger.NewNop(), countdown).ServeHTTP(w, r)
+
+			assert.Equal(t, test.status, w.Code)
+			assert.Equal(t, test.contentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, test.want, w.Body.String())
+		})
+	}
+}
+
+func TestWithStreamDisconnect(t *testing.T) {
+	t.Parallel()
+
+	stopped := make(chan struct{})
+
+	h := handler.WithStream(logger.NewNop(), func(ctx context.Context, _ struct{}) iter.Seq2[string, error] {
+		ch := make(chan string)
+
+		go func() {
+			defer close(stopped)
+
+			ch <- "hello\nworld"
+
+			<-ctx.Done()
+		}()
+
+		return handler.FromChan(ctx, ch)
+	}, handler.Heartbeat(5*time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequestWithContext(ctx, http.MethodGet, "/", nil))
+
+	<-stopped
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "data: hello\ndata: world\n\n")
+	assert.Contains(t, w.Body.String(), ":\n\n")
+}
+
+func TestWithNDJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		target string
+		opts   []handler.Option
+		status int
+		want   string
+	}{
+		"rows": {
+			target: "/",
+			opts:   nil,
+			status: http.StatusOK,
+			want:   `{"done":1}` + "\n" + `{"done":2}` + "\n" + `{"done":3}` + "\n",
+		},
+		"flush every row": {
+			target: "/?fail=4",
+			opts:   []handler.Option{handler.FlushInterval(0)},
+			status: http.StatusOK,
+			want:   `{"done":1}` + "\n" + `{"done":2}` + "\n" + `{"done":3}` + "\n",
+		},
+		"no heartbeats": {
+			target: "/",
+			opts:   []handler.Option{handler.Heartbeat(time.Microsecond)},
+			status: http.StatusOK,
+			want:   `{"done":1}` + "\n" + `{"done":2}` + "\n" + `{"done":3}` + "\n",
+		},
+		"trailing error": {
+			target: "/?fail=3",
+			opts:   nil,
+			status: http.StatusOK,
+			want:   `{"done":1}` + "\n" + `{"done":2}` + "\n" + `{"$error":{"error":"step failed"}}` + "\n",
+		},
+		"trailing problem": {
+			target: "/?fail=2",
+			opts:   []handler.Option{handler.ProblemDetails()},
+			status: http.StatusOK,
+			want:   `{"done":1}` + "\n" + `{"$error":{"detail":"step failed","instance":"/","status":409,"title":"Conflict","type":"about:blank"}}` + "\n",
+		},
+		"error before the first row": {
+			target: "/?fail=1",
+			opts:   nil,
+			status: http.StatusConflict,
+			want:   `{"error":"step failed"}` + "\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			handler.WithNDJSON(logger.NewNop(), countdown, test.opts...).ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.target, nil))
+
+			assert.Equal(t, test.status, w.Code)
+			assert.Equal(t, test.want, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"errors"
+	"maps"
+	"mime/multipart"
+	"net/http"
+	"slices"
+	"strconv"
+)
+
+// defaultMaxUploadBytes limits the size of multipart uploads, when the handler doesn't use the MaxBodyBytes option.
+const defaultMaxUploadBytes = 32 << 20
+
+// CodeTooLarge is the code of the field errors of uploaded files exceeding the MaxFileBytes option.
+const CodeTooLarge = "toolarge"
+
+// File is a file uploaded via a multipart form. Its content is read via Open.
+type File struct {
+	Field       string
+	Name        string
+	Size        int64
+	ContentType string
+	header      *multipart.FileHeader
+}
+
+// Open opens the content of the file, which is read from memory or from a temporary file.
+func (f *File) Open() (multipart.File, error) {
+	return f.header.Open() //nolint:wrapcheck
+}
+
+// Upload is the input of the handlers taking multipart forms, see WithUpload.
+// Form is bound from the form values via the `in` struct tags, like InputFromRequest does for query arguments.
+type Upload[Form any] struct {
+	Form  Form
+	Files []*File
+}
+
+// File returns the first file uploaded in the given form field, or nil if there is none.
+func (u *Upload[Form]) File(field string) *File {
+	for _, f := range u.Files {
+		if f.Field == field {
+			return f
+		}
+	}
+
+	return nil
+}
+
+// FilesOf returns all the files uploaded in the given form field.
+func (u *Upload[Form]) FilesOf(field string) []*File {
+	return slices.DeleteFunc(slices.Clone(u.Files), func(f *File) bool {
+		return f.Field != field
+	})
+}
+
+// readBody parses the multipart form, binds the form values and collects the files, checking their size.
+// The body is limited to defaultMaxUploadBytes, unless the handler sets its own limit.
+func (u *Upload[Form]) readBody(w http.ResponseWriter, r *http.Request, cfg *config) error {
+	if cfg.maxBodyBytes == 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, defaultMaxUploadBytes)
+	}
+
+	if err := r.ParseMultipartForm(cfg.maxMemory); err != nil {
+		var maxBytesErr *http.MaxBytesError
+
+		switch {
+		case errors.Is(err, http.ErrNotMultipart):
+			return NewStatusError(http.StatusUnsupportedMediaType, errors.Join(ErrUnsupportedMediaType, err))
+		case errors.As(err, &maxBytesErr):
+			return err //nolint:wrapcheck // Served with 413 by readBody.
+		default:
+			return errors.Join(ErrInvalidInput, err)
+		}
+	}
+
+	var tooLarge FieldErrors
+
+	// Sort the fields, so that the files are in a stable order.
+	for _, field := range slices.Sorted(maps.Keys(r.MultipartForm.File)) {
+		for _, header := range r.MultipartForm.File[field] {
+			if cfg.maxFileBytes > 0 && header.Size > cfg.maxFileBytes {
+				tooLarge = append(tooLarge, &FieldError{
+					Field:   field,
+					Source:  sourceForm,
+					Code:    CodeTooLarge,
+					Message: "file " + header.Filename + " exceeds " + strconv.FormatInt(cfg.maxFileBytes, 10) + " bytes",
+				})
+
+				continue
+			}
+
+			u.Files = append(u.Files, &File{
+				Field:       field,
+				Name:        header.Filename,
+				Size:        header.Size,
+				ContentType: header.Header.Get("Content-Type"),
+				header:      header,
+			})
+		}
+	}
+
+	if len(tooLarge) > 0 {
+		return NewStatusError(http.StatusRequestEntityTooLarge, tooLarge)
+	}
+
+	return bindForm(r, &u.Form)
+}
+
+// bodyReader is implemented by the inputs that read the request's body themselves, rather than via the BodyDecoder.
+type bodyReader interface {
+	readBody(w http.ResponseWriter, r *http.Request, cfg *config) error
+}
@@ -0,0 +1,173 @@
+package handler_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/luca-arch/go-goodies/handler"
+	"github.com/luca-arch/go-goodies/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Album struct {
+	Title string `in:"title,required"`
+}
+
+type Photo struct {
+	Field       string `json:"field"`
+	Name        string `json:"name"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"contentType"`
+	Content     string `json:"content"`
+}
+
+func TestWithUpload(t *testing.T) {
+	t.Parallel()
+
+	h := func(opts ...handler.Option) http.Handler {
+		return handler.WithUpload(logger.NewNop(), func(_ context.Context, _ struct{}, u *handler.Upload[Album]) ([]Photo, error) {
+			photos := []Photo{{Field: "", Name: u.Form.Title, Size: 0, ContentType: "", Content: ""}}
+
+			for _, file := range u.Files {
+				f, err := file.Open()
+				if err != nil {
+					return nil, err
+				}
+
+				content, err := io.ReadAll(f)
+				_ = f.Close()
+
+				if err != nil {
+					return nil, err
+				}
+
+				photos = append(photos, Photo{Field: file.Field, Name: file.Name, Size: file.Size, ContentType: file.ContentType, Content: string(content)})
+			}
+
+			return photos, nil
+		}, opts...)
+	}
+
+	tests := map[string]struct {
+		values      map[string]string
+		files       map[string]string
+		contentType string
+		opts        []handler.Option
+		status      int
+		want        string
+	}{
+		"ok": {
+			values:      map[string]string{"title": "Holidays"},
+			files:       map[string]string{"cover": "sea", "back": "mountain"},
+			contentType: "",
+			opts:        nil,
+			status:      http.StatusOK,
+			want: `[{"field":"","name":"Holidays","size":0,"contentType":"","content":""},` +
+				`{"field":"back","name":"mountain.png","size":8,"contentType":"image/png","content":"mountain"},` +
+				`{"field":"cover","name":"sea.png","size":3,"contentType":"image/png","content":"sea"}]` + "\n",
+		},
+		"temporary files": {
+			values:      map[string]string{"title": "Holidays"},
+			files:       map[string]string{"cover": "sea"},
+			contentType: "",
+			opts:        []handler.Option{handler.MaxMemory(1)},
+			status:      http.StatusOK,
+			want: `[{"field":"","name":"Holidays","size":0,"contentType":"","content":""},` +
+				`{"field":"cover","name":"sea.png","size":3,"contentType":"image/png","content":"sea"}]` + "\n",
+		},
+		"missing field": {
+			values:      nil,
+			files:       map[string]string{"cover": "sea"},
+			contentType: "",
+			opts:        nil,
+			status:      http.StatusBadRequest,
+			want:        `{"error":"invalid input\nmissing required field: title","fields":[{"field":"title","source":"form","code":"required","message":"missing required field: title"}]}` + "\n",
+		},
+		"file too large": {
+			values:      map[string]string{"title": "Holidays"},
+			files:       map[string]string{"cover": "sea", "back": "mountain"},
+			contentType: "",
+			opts:        []handler.Option{handler.MaxFileBytes(4)},
+			status:      http.StatusRequestEntityTooLarge,
+			want:        `{"error":"file mountain.png exceeds 4 bytes","fields":[{"field":"back","source":"form","code":"toolarge","message":"file mountain.png exceeds 4 bytes"}]}` + "\n",
+		},
+		"body too large": {
+			values:      map[string]string{"title": "Holidays"},
+			files:       map[string]string{"cover": "sea"},
+			contentType: "",
+			opts:        []handler.Option{handler.MaxBodyBytes(16)},
+			status:      http.StatusRequestEntityTooLarge,
+			want:        `{"error":"multipart: NextPart: http: request body too large"}` + "\n",
+		},
+		"not multipart": {
+			values:      nil,
+			files:       nil,
+			contentType: "application/json",
+			opts:        nil,
+			status:      http.StatusUnsupportedMediaType,
+			want:        `{"error":"unsupported media type\nrequest Content-Type isn't multipart/form-data"}` + "\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			body, contentType := multipartBody(t, test.values, test.files)
+			if test.contentType != "" {
+				contentType = test.contentType
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/albums", strings.NewReader(body))
+			r.Header.Set("Content-Type", contentType)
+
+			w := httptest.NewRecorder()
+			h(test.opts...).ServeHTTP(w, r)
+
+			assert.Equal(t, test.status, w.Code)
+			assert.Equal(t, test.want, w.Body.String())
+		})
+	}
+}
+
+//nolint:paralleltest // Sets TMPDIR, where the files exceeding MaxMemory are written.
+func TestWithUploadRemovesTemporaryFiles(t *testing.T) {
+	h := handler.WithUpload(logger.NewNop(), func(_ context.Context, _ struct{}, _ *handler.Upload[Album]) (struct{}, error) {
+		return struct{}{}, nil
+	}, handler.MaxMemory(1))
+
+	tests := map[string]struct {
+		values map[string]string
+		status int
+	}{
+		"ok":            {values: map[string]string{"title": "Holidays"}, status: http.StatusOK},
+		"missing field": {values: nil, status: http.StatusBadRequest},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Setenv("TMPDIR", dir)
+
+			body, contentType := multipartBody(t, test.values, map[string]string{"cover": "sea"})
+
+			r := httptest.NewRequest(http.MethodPost, "/albums", strings.NewReader(body))
+			r.Header.Set("Content-Type", contentType)
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			assert.Equal(t, test.status, w.Code)
+
+			entries, err := os.ReadDir(dir)
+			require.NoError(t, err)
+			assert.Empty(t, entries)
+		})
+	}
+}
@@ -0,0 +1,485 @@
+package handler
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Validation rules that can be declared in the `in` and `validate` struct tags.
+const (
+	ruleGtField  = "gtfield"
+	ruleGteField = "gtefield"
+	ruleLtField  = "ltfield"
+	ruleLteField = "ltefield"
+	ruleMax      = "max"
+	ruleMaxLen   = "maxlen"
+	ruleMin      = "min"
+	ruleMinLen   = "minlen"
+	ruleOneOf    = "oneof"
+	rulePattern  = "pattern"
+	ruleRequired = "required"
+)
+
+// patterns caches the compiled regular expressions used by the pattern rule.
+var patterns sync.Map //nolint:gochecknoglobals
+
+// Validator is implemented by types that check themselves once their declarative rules are satisfied.
+type Validator interface {
+	Validate() error
+}
+
+type rule struct {
+	name  string
+	param string
+}
+
+// Validate checks the rules declared in the `validate` struct tags of v, a struct or a pointer to a struct.
+// Nested structs are validated recursively, and types implementing Validator are checked last.
+// All the failures are returned together as FieldErrors.
+// Available rules are:
+//   - `validate:"required"` the value must not be the zero value.
+//   - `validate:"min=1,max=10"` numbers must be within the range.
+//   - `validate:"minlen=3,maxlen=20"` strings (in characters) and slices must have a length within the range.
+//   - `validate:"oneof=open|closed"` the value must be one of the listed ones.
+//   - `validate:"pattern=^[a-z]+$"` strings must match the regular expression (which cannot contain commas).
+//   - `validate:"gtfield=From"` (or gtefield, ltfield, ltefield) the value must be greater than another field of the same struct.
+//
+// For slices, all the rules but minlen and maxlen are checked against every element.
+// The same rules can be appended to the options of the `in` tag of the structs read by InputFromRequest.
+func Validate(v any) error {
+	value := reflect.ValueOf(v)
+	if !value.IsValid() {
+		return nil
+	}
+
+	// Work on an addressable copy so that pointer receivers of Validator are found too.
+	if value.Kind() != reflect.Ptr {
+		ptr := reflect.New(value.Type())
+		ptr.Elem().Set(value)
+		value = ptr
+	}
+
+	if errs := validateNested(value, ""); len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// validateStruct checks the `validate` tags of the struct fields, using their JSON name in error messages.
+func validateStruct(structValue reflect.Value, prefix string) FieldErrors {
+	var errs FieldErrors
+
+	structType := structValue.Type()
+
+	for i := range structType.NumField() {
+		field := structType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := prefix + jsonName(&field)
+		fieldValue := structValue.Field(i)
+
+		if tag := field.Tag.Get("validate"); tag != "" && tag != "-" {
+			if fieldErr := checkRules(structValue, fieldValue, name, parseRules(strings.Split(tag, ","))); fieldErr != nil {
+				fieldErr.Source = SourceBody
+				errs = append(errs, fieldErr)
+
+				continue
+			}
+		}
+
+		errs = append(errs, validateNested(fieldValue, name)...)
+	}
+
+	if len(errs) > 0 || !structValue.CanAddr() {
+		return errs
+	}
+
+	if validator, ok := structValue.Addr().Interface().(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			return toFieldErrors(err, strings.TrimSuffix(prefix, "."), SourceBody)
+		}
+	}
+
+	return nil
+}
+
+// validateNested validates structs that are contained in a struct field.
+func validateNested(fieldValue reflect.Value, name string) FieldErrors {
+	for fieldValue.Kind() == reflect.Ptr {
+		if fieldValue.IsNil() {
+			return nil
+		}
+
+		fieldValue = fieldValue.Elem()
+	}
+
+	switch fieldValue.Kind() { //nolint:exhaustive // Only containers are relevant.
+	case reflect.Struct:
+		if fieldValue.Type() == reflect.TypeOf(time.Time{}) || !fieldValue.CanAddr() {
+			return nil
+		}
+
+		if name != "" {
+			name += "."
+		}
+
+		return validateStruct(fieldValue, name)
+	case reflect.Slice, reflect.Array:
+		var errs FieldErrors
+
+		for i := range fieldValue.Len() {
+			errs = append(errs, validateNested(fieldValue.Index(i), name+"["+strconv.Itoa(i)+"]")...)
+		}
+
+		return errs
+	}
+
+	return nil
+}
+
+// jsonName returns the name of the field once encoded to JSON.
+func jsonName(field *reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+
+	return name
+}
+
+// parseRules returns the validation rules found among the tag options. Unknown options are ignored.
+func parseRules(options []string) []rule {
+	rules := make([]rule, 0, len(options))
+
+	for _, option := range options {
+		name, param, _ := strings.Cut(option, "=")
+
+		switch name {
+		case ruleGtField, ruleGteField, ruleLtField, ruleLteField,
+			ruleMax, ruleMaxLen, ruleMin, ruleMinLen, ruleOneOf, rulePattern, ruleRequired:
+			rules = append(rules, rule{name: name, param: param})
+		}
+	}
+
+	return rules
+}
+
+// mustCheckRules panics if the rules declared in the `in` and `validate` tags of the given types, or of the structs they
+// contain, have invalid parameters. Handlers check their types once built, so that mistakes in the tags aren't blamed
+// on the requests.
+func mustCheckRules(types ...reflect.Type) {
+	seen := map[reflect.Type]bool{}
+
+	for _, t := range types {
+		if err := checkRuleTags(t, seen); err != nil {
+			panic("handler: " + err.Error())
+		}
+	}
+}
+
+// checkRuleTags checks the parameters of the rules declared in the tags of t, and of the structs it contains.
+func checkRuleTags(t reflect.Type, seen map[reflect.Type]bool) error {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct || t == reflect.TypeFor[time.Time]() || seen[t] {
+		return nil
+	}
+
+	seen[t] = true
+
+	var errs []error
+
+	for _, field := range planFor(t, sourceQuery).fields {
+		errs = append(errs, checkRuleParams(t, field.field.Name, field.rules))
+	}
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		if tag := field.Tag.Get("validate"); tag != "" && tag != "-" {
+			errs = append(errs, checkRuleParams(t, field.Name, parseRules(strings.Split(tag, ","))))
+		}
+
+		errs = append(errs, checkRuleTags(field.Type, seen))
+	}
+
+	return errors.Join(errs...)
+}
+
+// checkRuleParams returns an error if the parameter of a rule is invalid, eg. a min that isn't a number,
+// or a gtfield naming a field that doesn't exist in the struct.
+func checkRuleParams(structType reflect.Type, name string, rules []rule) error {
+	for _, r := range rules {
+		var err error
+
+		switch r.name {
+		case ruleMin, ruleMax:
+			_, err = strconv.ParseFloat(r.param, 64)
+		case ruleMinLen, ruleMaxLen:
+			_, err = strconv.Atoi(r.param)
+		case rulePattern:
+			_, err = regexp.Compile(r.param)
+		case ruleGtField, ruleGteField, ruleLtField, ruleLteField:
+			if _, ok := structType.FieldByName(r.param); !ok {
+				err = errors.New("no such field " + r.param) //nolint:err113
+			}
+		}
+
+		if err != nil {
+			return errors.New("invalid " + r.name + " rule for field " + structType.String() + "." + name + ": " + err.Error()) //nolint:err113
+		}
+	}
+
+	return nil
+}
+
+// checkRules checks the value of a field against the given rules, and returns the first one that fails.
+// The struct the field belongs to is used to resolve the rules comparing two fields.
+func checkRules(structValue, fieldValue reflect.Value, name string, rules []rule) *FieldError {
+	for _, r := range rules {
+		if r.name == ruleRequired {
+			if fieldValue.IsZero() {
+				return &FieldError{Field: name, Code: CodeRequired, Message: "missing required field: " + name}
+			}
+
+			continue
+		}
+
+		value := fieldValue
+		if value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				continue
+			}
+
+			value = value.Elem()
+		}
+
+		if err := checkRule(structValue, value, name, r); err != nil {
+			return &FieldError{Field: name, Code: r.name, Message: err.Error()}
+		}
+	}
+
+	return nil
+}
+
+// checkRule checks a single rule. For slices, the length rules apply to the slice itself while the other ones apply to each element.
+func checkRule(structValue, value reflect.Value, name string, r rule) error {
+	if value.Kind() == reflect.Slice && r.name != ruleMinLen && r.name != ruleMaxLen {
+		for i := range value.Len() {
+			if err := checkRule(structValue, value.Index(i), name, r); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	switch r.name {
+	case ruleMin, ruleMax:
+		return checkBound(value, name, r)
+	case ruleMinLen, ruleMaxLen:
+		return checkLength(value, name, r)
+	case ruleOneOf:
+		return checkOneOf(value, name, r)
+	case rulePattern:
+		return checkPattern(value, name, r)
+	case ruleGtField, ruleGteField, ruleLtField, ruleLteField:
+		return checkField(structValue, value, name, r)
+	}
+
+	return nil
+}
+
+func checkBound(value reflect.Value, name string, r rule) error {
+	limit, err := strconv.ParseFloat(r.param, 64)
+	if err != nil {
+		return errors.New("invalid " + r.name + " rule for field: " + name) //nolint:err113
+	}
+
+	number, ok := toFloat(value)
+	if !ok {
+		return errors.New("cannot apply " + r.name + " rule to field: " + name) //nolint:err113
+	}
+
+	if r.name == ruleMin && number < limit {
+		return errors.New("field " + name + " must be at least " + r.param) //nolint:err113
+	}
+
+	if r.name == ruleMax && number > limit {
+		return errors.New("field " + name + " must be at most " + r.param) //nolint:err113
+	}
+
+	return nil
+}
+
+func checkLength(value reflect.Value, name string, r rule) error {
+	limit, err := strconv.Atoi(r.param)
+	if err != nil {
+		return errors.New("invalid " + r.name + " rule for field: " + name) //nolint:err113
+	}
+
+	var length int
+
+	switch value.Kind() { //nolint:exhaustive
+	case reflect.String:
+		length = utf8.RuneCountInString(value.String())
+	case reflect.Slice, reflect.Array, reflect.Map:
+		length = value.Len()
+	default:
+		return errors.New("cannot apply " + r.name + " rule to field: " + name) //nolint:err113
+	}
+
+	if r.name == ruleMinLen && length < limit {
+		return errors.New("field " + name + " must have a length of at least " + r.param) //nolint:err113
+	}
+
+	if r.name == ruleMaxLen && length > limit {
+		return errors.New("field " + name + " must have a length of at most " + r.param) //nolint:err113
+	}
+
+	return nil
+}
+
+func checkOneOf(value reflect.Value, name string, r rule) error {
+	var actual string
+
+	switch value.Kind() { //nolint:exhaustive
+	case reflect.String:
+		actual = value.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		actual = strconv.FormatInt(value.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		actual = strconv.FormatUint(value.Uint(), 10)
+	default:
+		return errors.New("cannot apply " + r.name + " rule to field: " + name) //nolint:err113
+	}
+
+	allowed := strings.Split(r.param, "|")
+
+	for _, a := range allowed {
+		if a == actual {
+			return nil
+		}
+	}
+
+	return errors.New("field " + name + " must be one of: " + strings.Join(allowed, ", ")) //nolint:err113
+}
+
+func checkPattern(value reflect.Value, name string, r rule) error {
+	if value.Kind() != reflect.String {
+		return errors.New("cannot apply " + r.name + " rule to field: " + name) //nolint:err113
+	}
+
+	var re *regexp.Regexp
+
+	if cached, ok := patterns.Load(r.param); ok {
+		re, _ = cached.(*regexp.Regexp)
+	} else {
+		compiled, err := regexp.Compile(r.param)
+		if err != nil {
+			return errors.New("invalid " + r.name + " rule for field: " + name) //nolint:err113
+		}
+
+		patterns.Store(r.param, compiled)
+
+		re = compiled
+	}
+
+	if !re.MatchString(value.String()) {
+		return errors.New("field " + name + " must match the pattern " + r.param) //nolint:err113
+	}
+
+	return nil
+}
+
+func checkField(structValue, value reflect.Value, name string, r rule) error {
+	other := structValue.FieldByName(r.param)
+	if !other.IsValid() {
+		return errors.New("invalid " + r.name + " rule for field: " + name) //nolint:err113
+	}
+
+	if other.Kind() == reflect.Ptr {
+		if other.IsNil() {
+			return nil
+		}
+
+		other = other.Elem()
+	}
+
+	cmp, ok := compareValues(value, other)
+	if !ok {
+		return errors.New("cannot apply " + r.name + " rule to field: " + name) //nolint:err113
+	}
+
+	switch {
+	case r.name == ruleGtField && cmp <= 0:
+		return errors.New("field " + name + " must be greater than " + r.param) //nolint:err113
+	case r.name == ruleGteField && cmp < 0:
+		return errors.New("field " + name + " must be greater than or equal to " + r.param) //nolint:err113
+	case r.name == ruleLtField && cmp >= 0:
+		return errors.New("field " + name + " must be less than " + r.param) //nolint:err113
+	case r.name == ruleLteField && cmp > 0:
+		return errors.New("field " + name + " must be less than or equal to " + r.param) //nolint:err113
+	}
+
+	return nil
+}
+
+// compareValues compares two numbers, strings or times. It returns false if they cannot be compared.
+func compareValues(a, b reflect.Value) (int, bool) {
+	if ta, ok := a.Interface().(time.Time); ok {
+		if tb, ok := b.Interface().(time.Time); ok {
+			return ta.Compare(tb), true
+		}
+
+		return 0, false
+	}
+
+	if a.Kind() == reflect.String && b.Kind() == reflect.String {
+		return strings.Compare(a.String(), b.String()), true
+	}
+
+	fa, okA := toFloat(a)
+	fb, okB := toFloat(b)
+
+	if !okA || !okB {
+		return 0, false
+	}
+
+	switch {
+	case fa < fb:
+		return -1, true
+	case fa > fb:
+		return 1, true
+	default:
+		return 0, true
+	}
+}
+
+// toFloat converts any numeric value to a float64.
+func toFloat(value reflect.Value) (float64, bool) {
+	switch value.Kind() { //nolint:exhaustive
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,165 @@
+package handler_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/luca-arch/go-goodies/handler"
+	"github.com/luca-arch/go-goodies/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+type Item struct {
+	Name     string `json:"name"     validate:"required,minlen=2"`
+	Quantity int    `json:"quantity" validate:"min=1"`
+}
+
+type Order struct {
+	Customer string  `json:"customer" validate:"required"`
+	Priority *int    `json:"priority" validate:"oneof=1|2|3"`
+	Items    []Item  `json:"items"    validate:"minlen=1"`
+	Min      float64 `json:"min"`
+	Max      float64 `json:"max"      validate:"gtfield=Min"`
+}
+
+type SelfValidated struct {
+	A int `json:"a"`
+	B int `json:"b"`
+}
+
+func (s *SelfValidated) Validate() error {
+	if s.A+s.B != 10 {
+		return errors.New("a and b must add up to 10")
+	}
+
+	return nil
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	var (
+		one  = 1
+		four = 4
+	)
+
+	tests := map[string]struct {
+		args any
+		want string
+	}{
+		"ok - valid struct": {
+			args: Order{
+				Customer: "acme",
+				Priority: &one,
+				Items:    []Item{{Name: "pen", Quantity: 1}},
+				Max:      1,
+			},
+		},
+		"ok - valid pointer to struct": {
+			args: &Order{
+				Customer: "acme",
+				Items:    []Item{{Name: "pen", Quantity: 1}},
+				Max:      1,
+			},
+		},
+		"ok - not a struct": {
+			args: 10,
+		},
+		"error - required field": {
+			args: Order{
+				Items: []Item{{Name: "pen", Quantity: 1}},
+				Max:   1,
+			},
+			want: "missing required field: customer",
+		},
+		"error - invalid enum and range": {
+			args: Order{
+				Customer: "acme",
+				Priority: &four,
+				Items:    []Item{{Name: "pen", Quantity: 1}},
+			},
+			want: "field priority must be one of: 1, 2, 3\nfield max must be greater than Min",
+		},
+		"error - nested struct": {
+			args: Order{
+				Customer: "acme",
+				Items:    []Item{{Name: "pen", Quantity: 1}, {Name: "p", Quantity: 0}},
+				Max:      1,
+			},
+			want: "field items[1].name must have a length of at least 2\nfield items[1].quantity must be at least 1",
+		},
+		"ok - self validated": {
+			args: SelfValidated{A: 3, B: 7},
+		},
+		"error - self validated": {
+			args: SelfValidated{A: 3, B: 3},
+			want: "a and b must add up to 10",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := handler.Validate(test.args)
+
+			if test.want != "" {
+				assert.EqualError(t, err, test.want)
+
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestInvalidRules(t *testing.T) {
+	t.Parallel()
+
+	type Bounds struct {
+		Min int `json:"min" validate:"min=one"`
+	}
+
+	type Window struct {
+		From int `json:"from"`
+		To   int `json:"to"   validate:"gtfield=Since"`
+	}
+
+	type Search struct {
+		Query string `in:"q,pattern=[a-z"`
+	}
+
+	tests := map[string]struct {
+		build func()
+		want  string
+	}{
+		"bound": {
+			build: func() {
+				handler.WithInput(logger.NewNop(), func(_ context.Context, _ Bounds) error { return nil })
+			},
+			want: `handler: invalid min rule for field handler_test.Bounds.Min: strconv.ParseFloat: parsing "one": invalid syntax`,
+		},
+		"nested field": {
+			build: func() {
+				handler.WithInput(logger.NewNop(), func(_ context.Context, _ []Window) error { return nil })
+			},
+			want: "handler: invalid gtfield rule for field handler_test.Window.To: no such field Since",
+		},
+		"argument pattern": {
+			build: func() {
+				handler.WithArgs(logger.NewNop(), func(_ context.Context, _ Search) error { return nil })
+			},
+			want: "handler: invalid pattern rule for field handler_test.Search.Query: error parsing regexp: missing closing ]: `[a-z`",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.PanicsWithValue(t, test.want, test.build)
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// NDJSONErrorMember is the only member of the record that terminates an NDJSON stream on error (eg. {"$error":{"error":"..."}}).
+// It is reserved: streamed rows must not have a member with this name.
+const NDJSONErrorMember = "$error"
+
+// WithNDJSON takes a FuncWithStream and uses it to create an HTTP handler that streams the outputs as newline-delimited JSON.
+// Records are flushed periodically (see FlushInterval), so that memory use doesn't grow with the size of the result.
+// An error yielded before any output is served as a regular error response. Afterwards, it terminates the stream as a last record,
+// holding either an ErrResponse or problem details under NDJSONErrorMember. Rows can be streamed straight from the database via postgres.SelectSeq.
+// The Heartbeat option doesn't apply, as NDJSON has no record that parsers are meant to ignore.
+func WithNDJSON[Args any, Out any](logger *slog.Logger, f FuncWithStream[Args, Out], opts ...Option) http.Handler {
+	cfg := newConfig(withLogger(logger, opts))
+
+	return streamHandler(cfg, f, ndjsonFormat{}, cfg.flushInterval)
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"log/slog"
 	"net/http"
 )
@@ -9,14 +10,9 @@ import (
 type FuncWithRequest[Out any] func(*http.Request) (Out, error)
 
 // WithRequest takes a FuncWithRequest and uses it to create an HTTP handler.
-func WithRequest[Out any](logger *slog.Logger, f FuncWithRequest[Out]) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		logger.Debug("HTTP request", "http.method", r.Method, "http.url", r.URL)
-
-		// Call out to target function.
-		out, err := f(r)
-
-		// Serve response.
-		writeResponse(w, logger, out, err)
-	})
+// Use New to receive the request along with its decoded arguments.
+func WithRequest[Out any](logger *slog.Logger, f FuncWithRequest[Out], opts ...Option) http.Handler {
+	return New(func(_ context.Context, r *Request[struct{}, struct{}]) (Out, error) {
+		return f(r.Request)
+	}, withLogger(logger, opts)...)
 }
@@ -0,0 +1,23 @@
+package handler
+
+import (
+	"context"
+	"iter"
+	"log/slog"
+	"net/http"
+)
+
+// FuncWithStream is an HTTP handler that takes a generic querystring input and returns a sequence of outputs.
+type FuncWithStream[Args any, Out any] func(context.Context, Args) iter.Seq2[Out, error]
+
+// WithStream takes a FuncWithStream and uses it to create an HTTP handler that sends each output as a Server-Sent Event.
+// Outputs of type Event set the ID, name and retry fields of their event; strings are sent as they are, other values as JSON.
+// Clients resuming a stream send the ID of the last event they received, which Args can read with `in:"Last-Event-ID,header"`.
+// The stream ends with the sequence, at the first error or when the client disconnects, which cancels the context.
+// An error yielded before any output is served as a regular error response, afterwards it is sent as an "error" event.
+// Channels can be streamed via FromChan, and idle connections kept open with the Heartbeat option.
+func WithStream[Args any, Out any](logger *slog.Logger, f FuncWithStream[Args, Out], opts ...Option) http.Handler {
+	cfg := newConfig(withLogger(logger, opts))
+
+	return streamHandler(cfg, f, sseFormat{}, 0)
+}
@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+)
+
+// FuncWithUpload is an HTTP handler that takes a generic querystring input and a multipart form, and returns a generic output.
+type FuncWithUpload[Args any, Form any, Out any] func(context.Context, Args, *Upload[Form]) (Out, error)
+
+// WithUpload takes a FuncWithUpload and uses it to create an HTTP handler that reads the request's querystring and multipart form.
+// Form values are bound via the `in` struct tags, while the files are exposed as File, whose content is read via Open.
+// See the MaxBodyBytes, MaxFileBytes and MaxMemory options to limit the size of the uploads, and their memory usage.
+// Unless MaxBodyBytes is set, uploads are limited to 32 MiB, as files exceeding MaxMemory are written to disk before being checked.
+// Temporary files are removed once the response is served.
+func WithUpload[Args any, Form any, Out any](logger *slog.Logger, f FuncWithUpload[Args, Form, Out], opts ...Option) http.Handler {
+	return New(func(ctx context.Context, r *Request[Args, Upload[Form]]) (Out, error) {
+		return f(ctx, r.Args, &r.In)
+	}, withLogger(logger, opts)...)
+}
@@ -0,0 +1,31 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+)
+
+type ctxKey struct{}
+
+// WithContext returns a copy of ctx carrying the logger, which can be retrieved with FromContext.
+func WithContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext returns the logger carried by ctx, or a silent logger if there is none.
+func FromContext(ctx context.Context) *slog.Logger {
+	return FromContextOr(ctx, nil)
+}
+
+// FromContextOr returns the logger carried by ctx, or fallback if there is none. A nil fallback means a silent logger.
+func FromContextOr(ctx context.Context, fallback *slog.Logger) *slog.Logger {
+	if l, ok := ctx.Value(ctxKey{}).(*slog.Logger); ok && l != nil {
+		return l
+	}
+
+	if fallback == nil {
+		return NewNop()
+	}
+
+	return fallback
+}
@@ -0,0 +1,58 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/luca-arch/go-goodies/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromContext(t *testing.T) {
+	t.Parallel()
+
+	var logs bytes.Buffer
+
+	fallback := slog.New(slog.NewTextHandler(&logs, nil))
+	scoped := fallback.With("request_id", "abc123")
+
+	tests := map[string]struct {
+		ctx      context.Context //nolint:containedctx
+		fallback *slog.Logger
+		want     string
+	}{
+		"scoped logger": {
+			ctx:      logger.WithContext(context.Background(), scoped),
+			fallback: fallback,
+			want:     "request_id=abc123",
+		},
+		"fallback": {
+			ctx:      context.Background(),
+			fallback: fallback,
+			want:     "msg=hello\n",
+		},
+		"silent": {
+			ctx:      context.Background(),
+			fallback: nil,
+			want:     "",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			logs.Reset()
+
+			logger.FromContextOr(test.ctx, test.fallback).Info("hello")
+
+			if test.want == "" {
+				assert.Empty(t, logs.String())
+			} else {
+				assert.Contains(t, logs.String(), test.want)
+			}
+		})
+	}
+
+	assert.NotNil(t, logger.FromContext(context.Background()))
+}
@@ -3,22 +3,42 @@ package postgres
 import (
 	"context"
 	"errors"
+	"iter"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/luca-arch/go-goodies/logger"
 )
 
+// uniqueViolation is the SQLSTATE code of unique constraint violations.
+const uniqueViolation = "23505"
+
 var (
 	ErrCount     = errors.New("count error")
 	ErrExecute   = errors.New("execute error")
+	ErrNoRows    = pgx.ErrNoRows
 	ErrSelect    = errors.New("select error")
 	ErrSelectOne = errors.New("select (one row) error")
 )
 
 type NamedArgs = pgx.NamedArgs
 
+// IsUniqueViolation reports whether err was caused by a unique constraint violation.
+func IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
+
+// logQuery logs the query with the logger carried by ctx (eg. the request-scoped one of the handler package),
+// falling back to the logger of the database.
+func logQuery(ctx context.Context, db *Database, sql string, args []any) {
+	logger.FromContextOr(ctx, db.logger).Debug("query", "sql", sql, "args", args)
+}
+
 // Count executes the provided SQL expecting a COUNT.
 func Count(ctx context.Context, db *Database, sql string, args ...any) (int64, error) {
-	db.logger.Debug("query", "sql", sql, "args", args)
+	logQuery(ctx, db, sql, args)
 
 	res, err := db.cnx.Query(ctx, sql, args...)
 	if err != nil {
@@ -43,7 +63,7 @@ func Count(ctx context.Context, db *Database, sql string, args ...any) (int64, e
 
 // Execute executes the provided SQL string without expecting anything to return.
 func Execute(ctx context.Context, db *Database, sql string, args ...any) error {
-	db.logger.Debug("query", "sql", sql, "args", args)
+	logQuery(ctx, db, sql, args)
 
 	res, err := db.cnx.Query(ctx, sql, args...)
 	if err != nil {
@@ -62,7 +82,7 @@ func Execute(ctx context.Context, db *Database, sql string, args ...any) error {
 // MustSelectOne executes the provided SQL and return the found row.
 // It returns an error if none, or if more than one rows are found.
 func MustSelectOne[T any](ctx context.Context, db *Database, sql string, args ...any) (*T, error) {
-	db.logger.Debug("query", "sql", sql, "args", args)
+	logQuery(ctx, db, sql, args)
 
 	res, err := db.cnx.Query(ctx, sql, args...)
 	if err != nil {
@@ -87,7 +107,7 @@ func MustSelectOne[T any](ctx context.Context, db *Database, sql string, args ..
 
 // Select executes the provided SQL and returns the whole resultset.
 func Select[T any](ctx context.Context, db *Database, sql string, args ...any) ([]T, error) {
-	db.logger.Debug("query", "sql", sql, "args", args)
+	logQuery(ctx, db, sql, args)
 
 	var out []T
 
@@ -112,6 +132,45 @@ func Select[T any](ctx context.Context, db *Database, sql string, args ...any) (
 	return out, nil
 }
 
+// SelectSeq executes the provided SQL and returns an iterator over the resultset, so that rows are never held in memory at once.
+// The query runs when the iteration starts, and its rows are released when the iteration ends.
+func SelectSeq[T any](ctx context.Context, db *Database, sql string, args ...any) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+
+		logQuery(ctx, db, sql, args)
+
+		res, err := db.cnx.Query(ctx, sql, args...)
+		if err != nil {
+			yield(zero, errors.Join(ErrSelect, err))
+
+			return
+		}
+
+		defer res.Close()
+
+		for res.Next() {
+			out, err := pgx.RowToStructByNameLax[T](res)
+			if err != nil {
+				yield(zero, errors.Join(ErrSelect, err))
+
+				return
+			}
+
+			if !yield(out, nil) {
+				return
+			}
+		}
+
+		// Rows MUST be closed prior to reading the error.
+		res.Close()
+
+		if err := res.Err(); err != nil {
+			yield(zero, errors.Join(ErrSelect, err))
+		}
+	}
+}
+
 // SelectOne executes the provided SQL and return the found row.
 // It returns an error if more than one rows are found.
 func SelectOne[T any](ctx context.Context, db *Database, sql string, args ...any) (*T, error) {